@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/spf13/pflag"
+)
+
+func ackCommand() *command {
+	var cluster string
+
+	return &command{
+		name:  "ack",
+		usage: "alertops ack <incident-id>... | --cluster <cluster>",
+		short: "Acknowledge incidents as the current user",
+		flags: func(fs *pflag.FlagSet) {
+			clusterFlag(fs, &cluster)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := targetArgs(args, cluster); err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			incidents, err := s.targets(ctx, args, cluster)
+			if err != nil {
+				return err
+			}
+
+			result, err := pd.AcknowledgeIncidentWithContext(ctx, s.PD.Client, incidents, s.PD.CurrentUser)
+			return printResults(result, err, "acknowledged")
+		},
+	}
+}
+
+func reassignCommand() *command {
+	var to []string
+
+	return &command{
+		name:  "reassign",
+		usage: "alertops reassign <incident-id>... --to <user-id>",
+		short: "Reassign incidents to one or more users",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringSliceVar(&to, "to", nil, "IDs of the users to assign the incidents to")
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := minArgs(args, 1, "at least one incident ID"); err != nil {
+				return err
+			}
+			if len(to) == 0 {
+				return usageErrorf("--to is required")
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			var users []*pagerduty.User
+			for _, id := range to {
+				user, err := pd.GetUserWithContext(ctx, s.PD.Client, id, pagerduty.GetUserOptions{})
+				if err != nil {
+					return err
+				}
+				users = append(users, user)
+			}
+
+			incidents, err := s.incidents(ctx, args)
+			if err != nil {
+				return err
+			}
+
+			result, err := pd.ReassignIncidentsWithContext(ctx, s.PD.Client, incidents, s.PD.CurrentUser, users)
+			return printResults(result, err, "reassigned to "+strings.Join(to, ", "))
+		},
+	}
+}
+
+func noteCommand() *command {
+	var message, cluster string
+
+	return &command{
+		name:  "note",
+		usage: "alertops note <incident-id> --message <text> | --cluster <cluster> --message <text>",
+		short: "Add a note to an incident, or to every incident of a cluster",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&message, "message", "m", "", "content of the note")
+			clusterFlag(fs, &cluster)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if cluster == "" {
+				if err := exactArgs(args, 1, "an incident ID"); err != nil {
+					return err
+				}
+			} else if err := targetArgs(args, cluster); err != nil {
+				return err
+			}
+			if strings.TrimSpace(message) == "" {
+				return usageErrorf("--message is required")
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			if cluster != "" {
+				incidents, err := s.targets(ctx, nil, cluster)
+				if err != nil {
+					return err
+				}
+
+				result, err := pd.PostNotesWithContext(ctx, s.PD.Client, incidents, s.PD.CurrentUser, message)
+				return printResults(result, err, "note added")
+			}
+
+			note, err := pd.PostNoteWithContext(ctx, s.PD.Client, args[0], s.PD.CurrentUser, message)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "note %v added to %v\n", note.ID, args[0])
+			return nil
+		},
+	}
+}
+
+func resolveCommand() *command {
+	var cluster string
+
+	return &command{
+		name:  "resolve",
+		usage: "alertops resolve <incident-id>... | --cluster <cluster>",
+		short: "Resolve incidents as the current user",
+		flags: func(fs *pflag.FlagSet) {
+			clusterFlag(fs, &cluster)
+		},
+		run: func(ctx context.Context, args []string) error {
+			return runOnTargets(ctx, args, cluster, "resolved", func(s *session, incidents []*pagerduty.Incident) (*pd.BulkResult, error) {
+				return pd.ResolveIncidentsWithContext(ctx, s.PD.Client, incidents, s.PD.CurrentUser)
+			})
+		},
+	}
+}
+
+func snoozeCommand() *command {
+	var cluster string
+	var duration time.Duration
+
+	return &command{
+		name:  "snooze",
+		usage: "alertops snooze <incident-id>... --for <duration> | --cluster <cluster> --for <duration>",
+		short: "Snooze acknowledged incidents, triggering them again after a while",
+		flags: func(fs *pflag.FlagSet) {
+			fs.DurationVar(&duration, "for", 0, "how long to snooze the incidents for, e.g. 30m or 4h")
+			clusterFlag(fs, &cluster)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if duration < time.Second {
+				return usageErrorf("--for is required and must be at least 1s")
+			}
+
+			return runOnTargets(ctx, args, cluster, "snoozed for "+duration.String(), func(s *session, incidents []*pagerduty.Incident) (*pd.BulkResult, error) {
+				return pd.SnoozeIncidentsWithContext(ctx, s.PD.Client, incidents, s.PD.CurrentUser, duration)
+			})
+		},
+	}
+}
+
+func escalateCommand() *command {
+	var cluster string
+
+	return &command{
+		name:  "escalate",
+		usage: "alertops escalate <incident-id>... | --cluster <cluster>",
+		short: "Escalate incidents to the next level of their escalation policy",
+		flags: func(fs *pflag.FlagSet) {
+			clusterFlag(fs, &cluster)
+		},
+		run: func(ctx context.Context, args []string) error {
+			return runOnTargets(ctx, args, cluster, "escalated", func(s *session, incidents []*pagerduty.Incident) (*pd.BulkResult, error) {
+				return pd.EscalateIncidentsWithContext(ctx, s.PD.Client, incidents, s.PD.CurrentUser)
+			})
+		},
+	}
+}
+
+func urgencyCommand() *command {
+	var cluster string
+
+	return &command{
+		name:  "urgency",
+		usage: "alertops urgency <high|low> <incident-id>... | alertops urgency <high|low> --cluster <cluster>",
+		short: "Change the urgency of incidents",
+		flags: func(fs *pflag.FlagSet) {
+			clusterFlag(fs, &cluster)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := minArgs(args, 1, "an urgency, high or low"); err != nil {
+				return err
+			}
+			urgency := args[0]
+			if urgency != "high" && urgency != "low" {
+				return usageErrorf("urgency must be high or low, got `%v`", urgency)
+			}
+
+			return runOnTargets(ctx, args[1:], cluster, "set to "+urgency+" urgency", func(s *session, incidents []*pagerduty.Incident) (*pd.BulkResult, error) {
+				return pd.SetUrgencyWithContext(ctx, s.PD.Client, incidents, s.PD.CurrentUser, urgency)
+			})
+		},
+	}
+}
+
+func priorityCommand() *command {
+	var cluster string
+
+	return &command{
+		name:  "priority",
+		usage: "alertops priority <priority> <incident-id>... | alertops priority <priority> --cluster <cluster>",
+		short: "Set the priority of incidents, by name (e.g. P1) or ID",
+		flags: func(fs *pflag.FlagSet) {
+			clusterFlag(fs, &cluster)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := minArgs(args, 1, "a priority"); err != nil {
+				return err
+			}
+			priority := args[0]
+
+			return runOnTargets(ctx, args[1:], cluster, "set to priority "+priority, func(s *session, incidents []*pagerduty.Incident) (*pd.BulkResult, error) {
+				return pd.SetPriorityWithContext(ctx, s.PD.Client, incidents, s.PD.CurrentUser, priority)
+			})
+		},
+	}
+}
+
+// runOnTargets applies a bulk operation to the incidents given as arguments, or to every open
+// incident of the cluster, and reports its outcome with printResults
+func runOnTargets(ctx context.Context, args []string, cluster, done string, action func(s *session, incidents []*pagerduty.Incident) (*pd.BulkResult, error)) error {
+	if err := targetArgs(args, cluster); err != nil {
+		return err
+	}
+
+	s, err := newSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	incidents, err := s.targets(ctx, args, cluster)
+	if err != nil {
+		return err
+	}
+
+	result, err := action(s, incidents)
+	return printResults(result, err, done)
+}
+
+// printResults reports the outcome of a bulk operation for each incident, the incidents it was
+// applied to on stdout and the failures on stderr, and returns an error if any incident failed
+func printResults(result *pd.BulkResult, err error, done string) error {
+	if result == nil {
+		return err
+	}
+
+	for _, r := range result.Results {
+		if r.Incident != nil {
+			fmt.Fprintf(stdout, "%v %v\n", r.ID, done)
+		}
+		if r.Err != nil {
+			fmt.Fprintf(stderr, "%v failed: %v\n", r.ID, r.Err)
+		}
+	}
+
+	return resultsError(result)
+}
+
+// resultsError returns an error counting the incidents a bulk operation failed on, if any
+func resultsError(result *pd.BulkResult) error {
+	if failed := result.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%v of %v incident(s) failed", len(failed), len(result.Results))
+	}
+	return nil
+}
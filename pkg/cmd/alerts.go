@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/spf13/pflag"
+)
+
+func alertsCommand() *command {
+	var format output.Format
+	var tmpl templateOptions
+	var labels, severities []string
+
+	return &command{
+		name:  "alerts",
+		usage: "alertops alerts <incident-id> [alert-id] [flags]",
+		short: "List the parsed alerts of an incident, or show one of them",
+		flags: func(fs *pflag.FlagSet) {
+			outputFlag(fs, &format)
+			templateFlags(fs, &tmpl)
+			fs.StringSliceVar(&severities, "severity", nil, "only list alerts with these severities (critical, error, warning, info)")
+			fs.StringArrayVarP(&labels, "label", "l", nil, "only list alerts whose labels or annotations match the `selector` key, key=value or key!=value (repeatable)")
+		},
+		run: func(ctx context.Context, args []string) error {
+			if len(args) != 1 && len(args) != 2 {
+				return usageErrorf("expected an incident ID and an optional alert ID, got %v argument(s)", len(args))
+			}
+
+			severities, err := pd.ParseSeverities(severities)
+			if err != nil {
+				return usageErrorf("%v", err)
+			}
+
+			t, err := tmpl.parse()
+			if err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			alerts, err := pd.GetAlertsWithContext(ctx, s.PD.Client, args[0], pagerduty.ListIncidentAlertsOptions{})
+			if err != nil {
+				return err
+			}
+
+			selector := pd.LabelSelector(labels)
+
+			var parsed []pd.Alert
+			for i := range alerts {
+				if len(args) == 2 && alerts[i].ID != args[1] {
+					continue
+				}
+
+				var a pd.Alert
+				// Partially parsed alerts are still listed, marked as partial
+				if err := a.ParseAlertDataWithContext(ctx, s.PD.Client, &alerts[i]); err != nil {
+					fmt.Fprintf(stderr, "warning: %v\n", err)
+				}
+
+				if selector.Matches(a) && (len(severities) == 0 || slices.Contains(severities, a.Severity)) {
+					parsed = append(parsed, a)
+				}
+			}
+
+			pd.SortAlertsBySeverity(parsed)
+
+			if len(args) == 2 {
+				if len(parsed) == 0 {
+					return fmt.Errorf("alert `%v` not found in incident `%v`", args[1], args[0])
+				}
+				if t != nil {
+					return output.PrintTemplate(stdout, t, parsed)
+				}
+				return printAlertDetail(stdout, format, parsed[0])
+			}
+
+			if t != nil {
+				return output.PrintTemplate(stdout, t, parsed)
+			}
+			return output.Print(stdout, format, parsed, alertColumns)
+		},
+	}
+}
+
+// printAlertDetail prints an alert, followed by its labels and annotations as key/value tables
+func printAlertDetail(w io.Writer, format output.Format, a pd.Alert) error {
+	if err := output.PrintDetail(w, format, a, alertColumns); err != nil {
+		return err
+	}
+
+	if format != output.Table && format != output.Wide {
+		return nil
+	}
+
+	for _, section := range []struct {
+		title  string
+		values map[string]string
+	}{
+		{"Labels", a.AlertLabels},
+		{"Annotations", a.Annotations},
+	} {
+		if len(section.values) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%v:\n", section.title)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, k := range pd.SortedKeys(section.values) {
+			fmt.Fprintf(tw, "  %v\t%v\n", k, section.values[k])
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/spf13/pflag"
+)
+
+func clustersCommand() *command {
+	var statuses []string
+	var workers int
+	var format output.Format
+	var tmpl templateOptions
+
+	return &command{
+		name:  "clusters",
+		usage: "alertops clusters [flags]",
+		short: "List the open incidents of the configured teams grouped by cluster",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringSliceVar(&statuses, "status", []string{"triggered", "acknowledged"}, "incident statuses to group (triggered, acknowledged, resolved)")
+			fs.IntVar(&workers, "workers", pd.DefaultEnrichWorkers, "number of incidents whose alerts are fetched concurrently")
+			outputFlag(fs, &format)
+			templateFlags(fs, &tmpl)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+
+			t, err := tmpl.parse()
+			if err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			groups, err := s.clusters(ctx, statuses, workers)
+			if err != nil {
+				return err
+			}
+
+			if t != nil {
+				return output.PrintTemplate(stdout, t, groups)
+			}
+			return output.Print(stdout, format, groups, clusterColumns)
+		},
+	}
+}
+
+// clusterFlag registers the --cluster flag of the commands that can act on every incident of a cluster
+func clusterFlag(fs *pflag.FlagSet, cluster *string) {
+	fs.StringVar(cluster, "cluster", "", "act on every open incident with an alert on the cluster with this ID or name, instead of incident IDs")
+}
+
+// clusters groups the incidents of the team members with the given statuses by cluster
+func (s *session) clusters(ctx context.Context, statuses []string, workers int) ([]pd.ClusterGroup, error) {
+	enriched, err := s.withAlerts(ctx, statuses, workers)
+	if err != nil {
+		return nil, err
+	}
+	return pd.GroupByCluster(enriched), nil
+}
+
+// withAlerts lists the incidents of the team members with the given statuses along with their parsed
+// alerts, warning about the alerts that could not be fetched or parsed
+func (s *session) withAlerts(ctx context.Context, statuses []string, workers int) ([]pd.EnrichedIncident, error) {
+	opts := pd.NewListIncidentOptsFromDefaults()
+	opts.Statuses = statuses
+	opts.UserIDs = s.teamUsers()
+
+	incidents, err := pd.GetIncidentsWithContext(ctx, s.PD.Client, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	enriched, err := pd.EnrichIncidentsWithContext(ctx, s.PD.Client, incidents, pd.EnrichOptions{
+		Workers:          workers,
+		WithoutNotes:     true,
+		WithoutAssignees: true,
+		WithoutService:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range enriched {
+		for _, msg := range e.Errors {
+			fmt.Fprintf(stderr, "warning: incident %v: %v\n", e.Incident.ID, msg)
+		}
+	}
+
+	return enriched, nil
+}
+
+// targets resolves the incidents an action applies to: those given as arguments, or every open
+// incident of the cluster if one was given
+func (s *session) targets(ctx context.Context, ids []string, cluster string) ([]*pagerduty.Incident, error) {
+	if cluster == "" {
+		return s.incidents(ctx, ids)
+	}
+
+	groups, err := s.clusters(ctx, []string{"triggered", "acknowledged"}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	group, ok := pd.FindClusterGroup(groups, cluster)
+	if !ok {
+		return nil, fmt.Errorf("no open incident on cluster `%v`", cluster)
+	}
+
+	var incidents []*pagerduty.Incident
+	for i := range group.Incidents {
+		incidents = append(incidents, &group.Incidents[i])
+	}
+	return incidents, nil
+}
+
+// targetArgs checks that either incident IDs or a cluster were given to an action
+func targetArgs(args []string, cluster string) error {
+	if (cluster == "") == (len(args) == 0) {
+		return usageErrorf("expected either incident IDs or --cluster")
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/spf13/pflag"
+)
+
+// defaultShift is the length of the shift a handoff report covers when neither --since nor --shift is given
+const defaultShift = 12 * time.Hour
+
+// Formats of the handoff report on top of the structured ones
+const (
+	handoffMarkdown = "markdown"
+	handoffHTML     = "html"
+	handoffText     = "text"
+)
+
+var handoffFormats = []string{handoffMarkdown, handoffHTML, handoffText, string(output.JSON), string(output.YAML)}
+
+func handoffCommand() *command {
+	var since string
+	var shift time.Duration
+	var format string
+	var top, workers int
+
+	return &command{
+		name:  "handoff",
+		usage: "alertops handoff [flags]",
+		short: "Summarise a shift for the next on-call: open, resolved and silenced incidents, noisy clusters and notes",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&since, "since", "", "start of the shift, an RFC3339 time or a duration ago such as 8h")
+			fs.DurationVar(&shift, "shift", 0, fmt.Sprintf("length of the shift, up to now if --since is not given (default %v without --since)", defaultShift))
+			fs.StringVar(&format, "format", handoffMarkdown, fmt.Sprintf("report format, one of %v", strings.Join(handoffFormats, ", ")))
+			fs.IntVar(&top, "top", pd.DefaultHandoffTopClusters, "number of the noisiest clusters to list")
+			fs.IntVar(&workers, "workers", pd.DefaultEnrichWorkers, "number of incidents whose alerts and notes are fetched concurrently")
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			if !slices.Contains(handoffFormats, format) {
+				return usageErrorf("--format must be one of %v, got `%v`", strings.Join(handoffFormats, ", "), format)
+			}
+			if top <= 0 {
+				return usageErrorf("--top must be positive")
+			}
+
+			start, end, err := shiftWindow(since, shift, time.Now())
+			if err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			h, err := pd.GetHandoffWithContext(ctx, s.PD, pd.HandoffOptions{
+				Since:       start,
+				Until:       end,
+				UserIDs:     s.teamUsers(),
+				TopClusters: top,
+				Workers:     workers,
+			})
+			if err != nil {
+				return err
+			}
+
+			switch format {
+			case handoffHTML:
+				page, err := h.HTML()
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprint(stdout, page)
+				return err
+			case handoffText:
+				_, err := fmt.Fprint(stdout, h.Text())
+				return err
+			case handoffMarkdown:
+				_, err := fmt.Fprint(stdout, h.Markdown())
+				return err
+			default:
+				return output.PrintDetail[pd.Handoff](stdout, output.Format(format), *h, nil)
+			}
+		},
+	}
+}
+
+// shiftWindow returns the start and end of the shift given by --since and --shift. With only one of
+// them the shift ends now, with both it lasts shift from since, but never past now.
+func shiftWindow(since string, shift time.Duration, now time.Time) (time.Time, time.Time, error) {
+	if shift < 0 {
+		return time.Time{}, time.Time{}, usageErrorf("--shift must be positive")
+	}
+
+	if since == "" {
+		if shift == 0 {
+			shift = defaultShift
+		}
+		return now.Add(-shift), now, nil
+	}
+
+	start, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		ago, durationErr := time.ParseDuration(since)
+		if durationErr != nil || ago <= 0 {
+			return time.Time{}, time.Time{}, usageErrorf("--since must be an RFC3339 time or a positive duration, got `%v`", since)
+		}
+		start = now.Add(-ago)
+	}
+
+	end := now
+	if shift > 0 && start.Add(shift).Before(now) {
+		end = start.Add(shift)
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, usageErrorf("--since must be in the past, got `%v`", since)
+	}
+
+	return start, end, nil
+}
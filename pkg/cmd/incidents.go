@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/spf13/pflag"
+)
+
+func incidentsCommand() *command {
+	return &command{
+		name:  "incidents",
+		usage: "alertops incidents <command> [flags]",
+		short: "Work with lists of incidents",
+		subcommands: []*command{
+			incidentsListCommand(),
+		},
+	}
+}
+
+func incidentsListCommand() *command {
+	var statuses, urgencies, users, severities []string
+	var allUsers bool
+	var sortBy string
+	var enrich bool
+	var workers int
+	var format output.Format
+	var tmpl templateOptions
+
+	return &command{
+		name:  "list",
+		usage: "alertops incidents list [flags]",
+		short: "List incidents assigned to the configured teams",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringSliceVar(&statuses, "status", []string{"triggered", "acknowledged"}, "incident statuses to list (triggered, acknowledged, resolved)")
+			fs.StringSliceVar(&urgencies, "urgency", nil, "incident urgencies to list (high, low)")
+			fs.StringSliceVar(&users, "user", nil, "only list incidents assigned to these user IDs")
+			fs.BoolVar(&allUsers, "all-users", false, "list incidents regardless of assignee")
+			fs.StringSliceVar(&severities, "severity", nil, "only list incidents whose most severe alert has one of these severities (critical, error, warning, info)")
+			fs.BoolVar(&enrich, "enrich", false, "attach the parsed alerts, notes, assignees and service of each incident to JSON, YAML and template output")
+			fs.IntVar(&workers, "workers", pd.DefaultEnrichWorkers, "number of incidents whose alerts are fetched concurrently")
+			fs.StringVar(&sortBy, "sort", "created", "sort incidents by `created` or by highest alert `severity`")
+			outputFlag(fs, &format)
+			templateFlags(fs, &tmpl)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+
+			if sortBy != "created" && sortBy != "severity" {
+				return usageErrorf("unknown sort order `%v`, must be created or severity", sortBy)
+			}
+
+			severities, err := pd.ParseSeverities(severities)
+			if err != nil {
+				return usageErrorf("%v", err)
+			}
+
+			t, err := tmpl.parse()
+			if err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			opts := pd.NewListIncidentOptsFromDefaults()
+			opts.Statuses = statuses
+			opts.Urgencies = urgencies
+
+			switch {
+			case len(users) > 0:
+				opts.UserIDs = users
+			case !allUsers:
+				opts.UserIDs = s.teamUsers()
+			}
+
+			incidents, err := pd.GetIncidentsWithContext(ctx, s.PD.Client, opts)
+			if err != nil {
+				return err
+			}
+
+			summaries := pd.NewIncidentSummaries(incidents)
+
+			// The severity of an incident comes from its alerts, so only fetch them when it is needed
+			if !enrich && len(severities) == 0 && sortBy != "severity" && format != output.Wide {
+				if t != nil {
+					return output.PrintTemplate(stdout, t, incidents)
+				}
+				return output.Print(stdout, format, summaries, incidentColumns)
+			}
+
+			enriched, err := pd.EnrichIncidentsWithContext(ctx, s.PD.Client, incidents, pd.EnrichOptions{
+				Workers:          workers,
+				WithoutNotes:     !enrich,
+				WithoutAssignees: !enrich,
+				WithoutService:   !enrich,
+			})
+			if err != nil {
+				return err
+			}
+
+			enriched, summaries = withSeverities(enriched, summaries, severities, sortBy == "severity")
+
+			if enrich {
+				for _, e := range enriched {
+					if len(e.Errors) > 0 {
+						fmt.Fprintf(stderr, "warning: incident %v: %v\n", e.Incident.ID, strings.Join(e.Errors, "; "))
+					}
+				}
+			}
+
+			switch {
+			case t != nil && enrich:
+				return output.PrintTemplate(stdout, t, enriched)
+			case t != nil:
+				incidents = []pagerduty.Incident{}
+				for _, e := range enriched {
+					incidents = append(incidents, e.Incident)
+				}
+				return output.PrintTemplate(stdout, t, incidents)
+			case enrich && (format == output.JSON || format == output.YAML):
+				return output.Print(stdout, format, enriched, nil)
+			default:
+				return output.Print(stdout, format, summaries, incidentColumns)
+			}
+		},
+	}
+}
+
+// withSeverities sets the severity of each incident summary, keeping only the incidents with one of the
+// given severities, if any, and sorting them from the most to the least severe if requested
+func withSeverities(incidents []pd.EnrichedIncident, summaries []pd.IncidentSummary, severities []string, sortBySeverity bool) ([]pd.EnrichedIncident, []pd.IncidentSummary) {
+	type entry struct {
+		incident pd.EnrichedIncident
+		summary  pd.IncidentSummary
+	}
+
+	var entries []entry
+	for i := range incidents {
+		if len(severities) > 0 && !slices.Contains(severities, incidents[i].Severity) {
+			continue
+		}
+
+		summaries[i].Severity = incidents[i].Severity
+		entries = append(entries, entry{incident: incidents[i], summary: summaries[i]})
+	}
+
+	if sortBySeverity {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return pd.SeverityRank(entries[i].summary.Severity) < pd.SeverityRank(entries[j].summary.Severity)
+		})
+	}
+
+	incidents, summaries = []pd.EnrichedIncident{}, []pd.IncidentSummary{}
+	for _, e := range entries {
+		incidents = append(incidents, e.incident)
+		summaries = append(summaries, e.summary)
+	}
+
+	return incidents, summaries
+}
+
+func incidentCommand() *command {
+	return &command{
+		name:  "incident",
+		usage: "alertops incident <command> [flags]",
+		short: "Work with a single incident",
+		subcommands: []*command{
+			incidentShowCommand(),
+			incidentTimelineCommand(),
+		},
+	}
+}
+
+func incidentShowCommand() *command {
+	var format output.Format
+	var tmpl templateOptions
+
+	return &command{
+		name:  "show",
+		usage: "alertops incident show <incident-id> [flags]",
+		short: "Show the details of an incident",
+		flags: func(fs *pflag.FlagSet) {
+			outputFlag(fs, &format)
+			templateFlags(fs, &tmpl)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 1, "an incident ID"); err != nil {
+				return err
+			}
+
+			t, err := tmpl.parse()
+			if err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			incident, err := pd.GetIncidentWithContext(ctx, s.PD.Client, args[0])
+			if err != nil {
+				return err
+			}
+
+			if t != nil {
+				return output.PrintTemplate(stdout, t, []pagerduty.Incident{*incident})
+			}
+			return output.PrintDetail(stdout, format, pd.NewIncidentSummary(*incident), incidentColumns)
+		},
+	}
+}
+
+func incidentTimelineCommand() *command {
+	var format output.Format
+	var markdown bool
+
+	return &command{
+		name:  "timeline",
+		usage: "alertops incident timeline <incident-id> [flags]",
+		short: "Show the log entries, alerts and notes of an incident in chronological order",
+		flags: func(fs *pflag.FlagSet) {
+			outputFlag(fs, &format)
+			fs.BoolVar(&markdown, "markdown", false, "print the timeline as a Markdown document, e.g. for a postmortem, overrides --output")
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 1, "an incident ID"); err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			timeline, err := pd.GetTimelineWithContext(ctx, s.PD.Client, args[0])
+			if err != nil {
+				return err
+			}
+
+			if markdown {
+				_, err := fmt.Fprint(stdout, timeline.Markdown())
+				return err
+			}
+			// Structured output keeps the incident the entries belong to
+			if format == output.JSON || format == output.YAML {
+				return output.PrintDetail[pd.Timeline](stdout, format, *timeline, nil)
+			}
+			return output.Print(stdout, format, timeline.Entries, timelineColumns)
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/spf13/pflag"
+)
+
+func notesCommand() *command {
+	var format output.Format
+
+	return &command{
+		name:  "notes",
+		usage: "alertops notes <incident-id> [flags]",
+		short: "List the notes of an incident",
+		flags: func(fs *pflag.FlagSet) {
+			outputFlag(fs, &format)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 1, "an incident ID"); err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			notes, err := pd.GetNotesWithContext(ctx, s.PD.Client, args[0])
+			if err != nil {
+				return err
+			}
+
+			return output.Print(stdout, format, pd.NewNoteSummaries(args[0], notes), noteColumns)
+		},
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/spf13/pflag"
+)
+
+// outputFlag registers the --output flag shared by every listing command
+func outputFlag(fs *pflag.FlagSet, format *output.Format) {
+	*format = output.Table
+	fs.VarP(format, "output", "o", fmt.Sprintf("output format, one of %v", output.Formats))
+}
+
+// templateOptions holds the --template and --template-file flags of the commands that can
+// render their items through a user supplied text/template
+type templateOptions struct {
+	text string
+	file string
+}
+
+func templateFlags(fs *pflag.FlagSet, o *templateOptions) {
+	fs.StringVar(&o.text, "template", "", "Go text/template to render each item with, overrides --output")
+	fs.StringVar(&o.file, "template-file", "", "file containing the Go text/template to render each item with, overrides --output")
+}
+
+// parse returns the user's template, or nil if the --output format should be used instead
+func (o *templateOptions) parse() (*template.Template, error) {
+	switch {
+	case o.text != "" && o.file != "":
+		return nil, usageErrorf("--template and --template-file are mutually exclusive")
+	case o.file != "":
+		b, err := os.ReadFile(o.file)
+		if err != nil {
+			return nil, usageErrorf("failed to read template file: %v", err)
+		}
+		o.text = string(b)
+	case o.text == "":
+		return nil, nil
+	}
+
+	t, err := output.ParseTemplate(o.text)
+	if err != nil {
+		return nil, &usageError{msg: err.Error()}
+	}
+	return t, nil
+}
+
+var incidentColumns = []output.Column[pd.IncidentSummary]{
+	{Header: "ID", Value: func(i pd.IncidentSummary) string { return i.ID }},
+	{Header: "NUMBER", Wide: true, Value: func(i pd.IncidentSummary) string { return fmt.Sprint(i.Number) }},
+	{Header: "STATUS", Value: func(i pd.IncidentSummary) string { return i.Status }},
+	{Header: "URGENCY", Value: func(i pd.IncidentSummary) string { return i.Urgency }},
+	{Header: "PRIORITY", Wide: true, Value: func(i pd.IncidentSummary) string { return i.Priority }},
+	{Header: "SEVERITY", Wide: true, Value: func(i pd.IncidentSummary) string { return i.Severity }},
+	{Header: "CREATED", Value: func(i pd.IncidentSummary) string { return i.CreatedAt }},
+	{Header: "SERVICE", Value: func(i pd.IncidentSummary) string { return i.Service }},
+	{Header: "ASSIGNEES", Wide: true, Value: func(i pd.IncidentSummary) string { return strings.Join(i.Assignees, ", ") }},
+	{Header: "ALERTS", Wide: true, Value: func(i pd.IncidentSummary) string { return fmt.Sprintf("%v/%v", i.AlertsTriggered, i.AlertsTotal) }},
+	{Header: "TITLE", Value: func(i pd.IncidentSummary) string { return i.Title }},
+	{Header: "URL", Wide: true, Value: func(i pd.IncidentSummary) string { return i.WebURL }},
+}
+
+var alertColumns = []output.Column[pd.Alert]{
+	{Header: "ID", Value: func(a pd.Alert) string { return a.AlertID }},
+	{Header: "INCIDENT", Wide: true, Value: func(a pd.Alert) string { return a.IncidentID }},
+	{Header: "STATUS", Value: func(a pd.Alert) string { return a.Status }},
+	{Header: "SEVERITY", Value: func(a pd.Alert) string { return a.Severity }},
+	{Header: "CLUSTER ID", Value: func(a pd.Alert) string { return a.ClusterID }},
+	{Header: "CLUSTER NAME", Value: func(a pd.Alert) string { return a.ClusterName }},
+	{Header: "NAME", Value: func(a pd.Alert) string { return a.Name }},
+	{Header: "CONSOLE", Wide: true, Value: func(a pd.Alert) string { return a.Console }},
+	{Header: "HOSTNAME", Wide: true, Value: func(a pd.Alert) string { return a.Hostname }},
+	{Header: "IP", Wide: true, Value: func(a pd.Alert) string { return a.IP }},
+	{Header: "LAST CHECK-IN", Wide: true, Value: func(a pd.Alert) string { return a.LastCheckIn }},
+	{Header: "SOP", Value: func(a pd.Alert) string { return a.Sop }},
+	{Header: "LABELS", Wide: true, Value: func(a pd.Alert) string { return pd.FormatLabels(a.AlertLabels) }},
+	{Header: "URL", Wide: true, Value: func(a pd.Alert) string { return a.WebURL }},
+	{Header: "PARSER", Wide: true, Value: func(a pd.Alert) string { return a.Parser }},
+	{Header: "PARSED", Value: func(a pd.Alert) string {
+		if a.Partial {
+			return "partial"
+		}
+		return "full"
+	}},
+	{Header: "PARSE ERROR", Wide: true, Value: func(a pd.Alert) string { return a.ParseError }},
+}
+
+var clusterColumns = []output.Column[pd.ClusterGroup]{
+	{Header: "CLUSTER ID", Value: func(g pd.ClusterGroup) string { return g.ClusterID }},
+	{Header: "CLUSTER NAME", Value: func(g pd.ClusterGroup) string { return g.ClusterName }},
+	{Header: "INCIDENTS", Value: func(g pd.ClusterGroup) string { return fmt.Sprint(len(g.IncidentIDs)) }},
+	{Header: "ALERTS", Value: func(g pd.ClusterGroup) string { return fmt.Sprint(g.Alerts) }},
+	{Header: "URGENCY", Value: func(g pd.ClusterGroup) string { return g.HighestUrgency }},
+	{Header: "SEVERITY", Value: func(g pd.ClusterGroup) string { return g.Severity }},
+	{Header: "OLDEST TRIGGER", Value: func(g pd.ClusterGroup) string { return g.OldestTrigger }},
+	{Header: "INCIDENT IDS", Wide: true, Value: func(g pd.ClusterGroup) string { return strings.Join(g.IncidentIDs, ", ") }},
+	{Header: "SOPS", Value: func(g pd.ClusterGroup) string { return strings.Join(g.Sops, ", ") }},
+}
+
+var problemColumns = []output.Column[pd.Problem]{
+	{Header: "ID", Value: func(p pd.Problem) string { return p.ID }},
+	{Header: "CLUSTER ID", Wide: true, Value: func(p pd.Problem) string { return p.ClusterID }},
+	{Header: "CLUSTER NAME", Value: func(p pd.Problem) string { return p.ClusterName }},
+	{Header: "INCIDENTS", Value: func(p pd.Problem) string { return strings.Join(p.IncidentIDs, ", ") }},
+	{Header: "ALERTS", Value: func(p pd.Problem) string { return fmt.Sprint(p.Alerts) }},
+	{Header: "URGENCY", Value: func(p pd.Problem) string { return p.HighestUrgency }},
+	{Header: "SEVERITY", Value: func(p pd.Problem) string { return p.Severity }},
+	{Header: "FIRST TRIGGER", Value: func(p pd.Problem) string { return p.FirstTrigger }},
+	{Header: "LAST TRIGGER", Wide: true, Value: func(p pd.Problem) string { return p.LastTrigger }},
+	{Header: "ALERT NAMES", Value: func(p pd.Problem) string { return strings.Join(p.AlertNames, ", ") }},
+}
+
+var timelineColumns = []output.Column[pd.TimelineEntry]{
+	{Header: "TIME", Value: func(e pd.TimelineEntry) string { return e.At }},
+	{Header: "ELAPSED", Value: func(e pd.TimelineEntry) string { return e.Elapsed }},
+	{Header: "DELTA", Wide: true, Value: func(e pd.TimelineEntry) string { return e.Delta }},
+	{Header: "SOURCE", Wide: true, Value: func(e pd.TimelineEntry) string { return e.Source }},
+	{Header: "TYPE", Value: func(e pd.TimelineEntry) string { return e.Type }},
+	{Header: "ACTOR", Value: func(e pd.TimelineEntry) string { return e.Actor }},
+	{Header: "STATUS", Value: func(e pd.TimelineEntry) string { return e.Transition }},
+	{Header: "SUMMARY", Value: func(e pd.TimelineEntry) string { return e.Summary }},
+}
+
+var noteColumns = []output.Column[pd.NoteSummary]{
+	{Header: "ID", Value: func(n pd.NoteSummary) string { return n.ID }},
+	{Header: "INCIDENT", Wide: true, Value: func(n pd.NoteSummary) string { return n.IncidentID }},
+	{Header: "CREATED", Value: func(n pd.NoteSummary) string { return n.CreatedAt }},
+	{Header: "AUTHOR", Value: func(n pd.NoteSummary) string { return n.Author }},
+	{Header: "CONTENT", Value: func(n pd.NoteSummary) string { return n.Content }},
+}
+
+var teamColumns = []output.Column[pd.TeamSummary]{
+	{Header: "ID", Value: func(t pd.TeamSummary) string { return t.ID }},
+	{Header: "NAME", Value: func(t pd.TeamSummary) string { return t.Name }},
+	{Header: "MEMBERS", Value: func(t pd.TeamSummary) string { return fmt.Sprint(len(t.MemberIDs)) }},
+	{Header: "DESCRIPTION", Wide: true, Value: func(t pd.TeamSummary) string { return t.Description }},
+	{Header: "URL", Wide: true, Value: func(t pd.TeamSummary) string { return t.WebURL }},
+}
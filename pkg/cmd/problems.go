@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/spf13/pflag"
+)
+
+func problemsCommand() *command {
+	return &command{
+		name:  "problems",
+		usage: "alertops problems <command> [flags]",
+		short: "Work with groups of incidents sharing a root cause",
+		subcommands: []*command{
+			problemsListCommand(),
+			problemsMergeCommand(),
+		},
+	}
+}
+
+// correlationOptions holds the flags tuning how incidents are correlated into problems
+type correlationOptions struct {
+	window     time.Duration
+	similarity float64
+	workers    int
+}
+
+func correlationFlags(fs *pflag.FlagSet, o *correlationOptions) {
+	fs.DurationVar(&o.window, "window", pd.DefaultCorrelationWindow, "how far apart incidents can be triggered and still be correlated")
+	fs.Float64Var(&o.similarity, "similarity", pd.DefaultCorrelationSimilarity, "share of labels, from 0 to 1, alerts with different names must have in common to be correlated")
+	fs.IntVar(&o.workers, "workers", pd.DefaultEnrichWorkers, "number of incidents whose alerts are fetched concurrently")
+}
+
+func (o *correlationOptions) validate() error {
+	if o.window <= 0 {
+		return usageErrorf("--window must be positive")
+	}
+	if o.similarity <= 0 || o.similarity > 1 {
+		return usageErrorf("--similarity must be greater than 0 and at most 1")
+	}
+	return nil
+}
+
+func problemsListCommand() *command {
+	var correlation correlationOptions
+	var all bool
+	var format output.Format
+	var tmpl templateOptions
+
+	return &command{
+		name:  "list",
+		usage: "alertops problems list [flags]",
+		short: "List the open incidents of the configured teams correlated into problems",
+		flags: func(fs *pflag.FlagSet) {
+			correlationFlags(fs, &correlation)
+			fs.BoolVar(&all, "all", false, "also list the incidents nothing correlates with, as problems of their own")
+			outputFlag(fs, &format)
+			templateFlags(fs, &tmpl)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			if err := correlation.validate(); err != nil {
+				return err
+			}
+
+			t, err := tmpl.parse()
+			if err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			problems, err := s.problems(ctx, correlation)
+			if err != nil {
+				return err
+			}
+
+			var shown []pd.Problem
+			for _, p := range problems {
+				if all || len(p.IncidentIDs) > 1 {
+					shown = append(shown, p)
+				}
+			}
+
+			if t != nil {
+				return output.PrintTemplate(stdout, t, shown)
+			}
+			return output.Print(stdout, format, shown, problemColumns)
+		},
+	}
+}
+
+func problemsMergeCommand() *command {
+	var correlation correlationOptions
+	var dryRun bool
+
+	return &command{
+		name:  "merge",
+		usage: "alertops problems merge <problem-id> [flags]",
+		short: "Merge the incidents of a problem into its primary incident",
+		flags: func(fs *pflag.FlagSet) {
+			correlationFlags(fs, &correlation)
+			fs.BoolVar(&dryRun, "dry-run", false, "only print which incidents would be merged")
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 1, "a problem ID"); err != nil {
+				return err
+			}
+			if err := correlation.validate(); err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			problems, err := s.problems(ctx, correlation)
+			if err != nil {
+				return err
+			}
+
+			problem, ok := pd.FindProblem(problems, args[0])
+			if !ok {
+				return fmt.Errorf("no open problem or incident `%v`", args[0])
+			}
+			if len(problem.Duplicates) == 0 {
+				return fmt.Errorf("problem `%v` has a single incident, there is nothing to merge", problem.ID)
+			}
+
+			if dryRun {
+				for _, i := range problem.Duplicates {
+					fmt.Fprintf(stdout, "%v would be merged into %v\n", i.ID, problem.ID)
+				}
+				return nil
+			}
+
+			if _, err := pd.MergeIncidentsWithContext(ctx, s.PD.Client, &problem.Primary, problem.Duplicates, s.PD.CurrentUser); err != nil {
+				return err
+			}
+
+			for _, i := range problem.Duplicates {
+				fmt.Fprintf(stdout, "%v merged into %v\n", i.ID, problem.ID)
+			}
+			return nil
+		},
+	}
+}
+
+// problems correlates the open incidents of the team members into problems
+func (s *session) problems(ctx context.Context, o correlationOptions) ([]pd.Problem, error) {
+	enriched, err := s.withAlerts(ctx, []string{"triggered", "acknowledged"}, o.workers)
+	if err != nil {
+		return nil, err
+	}
+	return pd.Correlate(enriched, pd.CorrelationOptions{Window: o.window, Similarity: o.similarity}), nil
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	utils "github.com/aliceh/alertops/pkg/utils"
+	"github.com/spf13/pflag"
+)
+
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitInterrupted = 130
+)
+
+// command is a single node of the alertops command tree. A command either runs
+// something itself or dispatches to one of its subcommands.
+type command struct {
+	name        string
+	usage       string
+	short       string
+	subcommands []*command
+
+	// flags registers the command's flags on the given flag set
+	flags func(fs *pflag.FlagSet)
+
+	// noTimeout hides the --timeout flag from commands that run until the user stops them
+	noTimeout bool
+
+	// run is called with the positional arguments left after flag parsing. ctx is cancelled
+	// on SIGINT/SIGTERM or once --timeout has passed.
+	run func(ctx context.Context, args []string) error
+}
+
+// usageError indicates the command line was invalid, as opposed to a failure
+// while talking to PagerDuty
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, a ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, a...)}
+}
+
+var stdout io.Writer = os.Stdout
+var stderr io.Writer = os.Stderr
+
+// offlineDemo is set by --offline-demo to run commands against built-in demo data
+var offlineDemo bool
+
+// noCache and cacheStats are set by --no-cache and --cache-stats
+var noCache, cacheStats bool
+
+// debug is set by --debug
+var debug bool
+
+func rootCommand() *command {
+	return &command{
+		name:  "alertops",
+		usage: "alertops <command> [flags]",
+		short: "Work with PagerDuty incidents and alerts from the terminal",
+		subcommands: []*command{
+			incidentsCommand(),
+			incidentCommand(),
+			alertsCommand(),
+			clustersCommand(),
+			problemsCommand(),
+			handoffCommand(),
+			ackCommand(),
+			resolveCommand(),
+			snoozeCommand(),
+			escalateCommand(),
+			urgencyCommand(),
+			priorityCommand(),
+			reassignCommand(),
+			noteCommand(),
+			notesCommand(),
+			silenceCommand(),
+			unsilenceCommand(),
+			teamsCommand(),
+			uiCommand(),
+		},
+	}
+}
+
+// Execute runs the alertops command line with the given arguments and returns
+// the process exit code.
+func Execute(args []string) int {
+	utils.InitLogger(stderr)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := rootCommand().execute(ctx, nil, args)
+	if err == nil {
+		return exitOK
+	}
+
+	if errors.Is(err, pflag.ErrHelp) {
+		return exitOK
+	}
+
+	if ctx.Err() != nil {
+		fmt.Fprintf(stderr, "Interrupted: %v\n", err)
+		return exitInterrupted
+	}
+
+	fmt.Fprintf(stderr, "Error: %v\n", err)
+
+	var u *usageError
+	if errors.As(err, &u) {
+		return exitUsage
+	}
+
+	return exitError
+}
+
+func (c *command) execute(ctx context.Context, parents []string, args []string) error {
+	path := append(parents, c.name)
+
+	if len(c.subcommands) > 0 {
+		if len(args) == 0 {
+			c.printUsage(stderr, nil)
+			return usageErrorf("%v: missing command", strings.Join(path, " "))
+		}
+
+		switch args[0] {
+		case "-h", "--help", "help":
+			c.printUsage(stdout, nil)
+			return nil
+		}
+
+		for _, sub := range c.subcommands {
+			if sub.name == args[0] {
+				return sub.execute(ctx, path, args[1:])
+			}
+		}
+
+		c.printUsage(stderr, nil)
+		return usageErrorf("%v: unknown command `%v`", strings.Join(path, " "), args[0])
+	}
+
+	fs := pflag.NewFlagSet(strings.Join(path, " "), pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if c.flags != nil {
+		c.flags(fs)
+	}
+
+	var timeout time.Duration
+	if !c.noTimeout {
+		fs.DurationVar(&timeout, "timeout", 0, "give up on the command after this long, 0 for no limit")
+	}
+	fs.BoolVar(&offlineDemo, "offline-demo", false, "use built-in demo data instead of PagerDuty, changes are not persisted")
+	fs.BoolVar(&noCache, "no-cache", false, "always fetch services, users and teams from PagerDuty")
+	fs.BoolVar(&cacheStats, "cache-stats", false, "print cache statistics to stderr when the command ends")
+	fs.BoolVar(&debug, "debug", false, "print retries of PagerDuty calls, and retry statistics when the command ends")
+
+	err := fs.Parse(args)
+	if errors.Is(err, pflag.ErrHelp) {
+		c.printUsage(stdout, fs)
+		return err
+	}
+	if err != nil {
+		c.printUsage(stderr, fs)
+		return &usageError{msg: err.Error()}
+	}
+
+	if timeout < 0 {
+		return usageErrorf("--timeout must be positive")
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err = c.run(ctx, fs.Args())
+
+	// Failing to persist the cache only costs lookups in a later run
+	if sessionCache != nil {
+		if err := sessionCache.Save(); err != nil {
+			debugf("%v", err)
+		}
+	}
+	if cacheStats && sessionCache != nil {
+		fmt.Fprint(stderr, pd.FormatCacheStats(sessionCache.Stats()))
+	}
+	if debug && sessionRetries != nil {
+		fmt.Fprint(debugOutput, pd.FormatRetryStats(sessionRetries.Stats()))
+	}
+
+	return err
+}
+
+func (c *command) printUsage(w io.Writer, fs *pflag.FlagSet) {
+	fmt.Fprintf(w, "%v\n\nUsage:\n  %v\n", c.short, c.usage)
+
+	if len(c.subcommands) > 0 {
+		subs := append([]*command{}, c.subcommands...)
+		sort.Slice(subs, func(i, j int) bool { return subs[i].name < subs[j].name })
+
+		fmt.Fprintf(w, "\nCommands:\n")
+		for _, sub := range subs {
+			fmt.Fprintf(w, "  %-12v %v\n", sub.name, sub.short)
+		}
+	}
+
+	if fs != nil && fs.HasFlags() {
+		fmt.Fprintf(w, "\nFlags:\n%v", fs.FlagUsages())
+	}
+}
+
+// exactArgs returns a usage error unless exactly n positional arguments were given
+func exactArgs(args []string, n int, what string) error {
+	if len(args) != n {
+		return usageErrorf("expected %v, got %v argument(s)", what, len(args))
+	}
+	return nil
+}
+
+// minArgs returns a usage error unless at least n positional arguments were given
+func minArgs(args []string, n int, what string) error {
+	if len(args) < n {
+		return usageErrorf("expected %v", what)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/PagerDuty/go-pagerduty"
+	config "github.com/aliceh/alertops/pkg/config"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+	utils "github.com/aliceh/alertops/pkg/utils"
+)
+
+// session holds the loaded configuration and the PagerDuty client shared by every command
+type session struct {
+	Config *config.Config
+	PD     *pd.Config
+}
+
+func newSession(ctx context.Context) (*session, error) {
+	if offlineDemo {
+		return newDemoSession(ctx)
+	}
+
+	cfg, err := config.LoadConfig(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	if err := pd.RegisterAlertMappings(cfg.AlertMappings); err != nil {
+		return nil, fmt.Errorf("failed to load alert mappings: %v", err)
+	}
+
+	if err := pd.SetClusterNameResolvers(cfg.ClusterNames); err != nil {
+		return nil, fmt.Errorf("failed to load cluster name resolvers: %v", err)
+	}
+
+	client, err := withCache(withRetries(pd.NewClient(cfg.Token, cfg.APIURL), cfg), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := pd.NewConfigWithClient(ctx, client, cfg.Teams, cfg.SilentUser, cfg.IgnoredUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{Config: &cfg, PD: c}, nil
+}
+
+// newDemoSession runs against an in-memory PagerDuty seeded with demo data instead of the real API
+func newDemoSession(ctx context.Context) (*session, error) {
+	cfg := config.Config{
+		Teams:      []string{fake.DemoTeamID},
+		SilentUser: fake.DemoSilentUserID,
+	}
+
+	// The demo data only lives as long as the command, so it is never cached on disk
+	client, err := withCache(withRetries(fake.NewDemo(), config.Config{}), config.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := pd.NewConfigWithClient(ctx, client, cfg.Teams, cfg.SilentUser, cfg.IgnoredUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{Config: &cfg, PD: c}, nil
+}
+
+// sessionRetries is the retrying client of the current session, kept to report its statistics
+var sessionRetries *pd.RetryingClient
+
+// debugOutput is where --debug writes to
+var debugOutput io.Writer = os.Stderr
+
+// withRetries wraps the client to retry throttled and failed calls, logging each retry with --debug
+func withRetries(client pd.PagerDutyClient, cfg config.Config) pd.PagerDutyClient {
+	opts := pd.RetryOptions{MaxRetries: -1}
+	if cfg.MaxRetries != nil {
+		opts.MaxRetries = *cfg.MaxRetries
+	}
+
+	retrying := pd.NewRetryingClient(client, opts)
+	retrying.Logf = debugf
+
+	sessionRetries = retrying
+	return retrying
+}
+
+// debugf writes a line of debug output if --debug was given
+func debugf(format string, args ...any) {
+	if debug {
+		fmt.Fprintf(debugOutput, "debug: "+format+"\n", args...)
+	}
+}
+
+// sessionCache is the cache of the current session, kept to report its statistics and save it
+var sessionCache *pd.CachingClient
+
+// withCache wraps the client in a cache unless --no-cache was given
+func withCache(client pd.PagerDutyClient, cfg config.Config) (pd.PagerDutyClient, error) {
+	if noCache {
+		return client, nil
+	}
+
+	var path string
+	if cfg.DiskCache {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the cache directory: %v", err)
+		}
+
+		// Keep accounts and API endpoints apart
+		h := fnv.New64a()
+		h.Write([]byte(cfg.APIURL + "\x00" + cfg.Token))
+		path = filepath.Join(dir, "alertops", fmt.Sprintf("pagerduty-%x.json", h.Sum64()))
+	}
+
+	cache, err := pd.NewCachingClient(client, cfg.CacheTTL, path)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionCache = cache
+	return cache, nil
+}
+
+// teamUsers returns the IDs of the team members, minus the ignored users
+func (s *session) teamUsers() []string {
+	return utils.DifferenceOfSlices(s.PD.TeamsMemberIDs, s.Config.IgnoredUsers)
+}
+
+// incidents resolves each incident ID into a PagerDuty incident
+func (s *session) incidents(ctx context.Context, ids []string) ([]*pagerduty.Incident, error) {
+	var incidents []*pagerduty.Incident
+
+	for _, id := range ids {
+		incident, err := pd.GetIncidentWithContext(ctx, s.PD.Client, id)
+		if err != nil {
+			return incidents, err
+		}
+		incidents = append(incidents, incident)
+	}
+
+	return incidents, nil
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	config "github.com/aliceh/alertops/pkg/config"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+func TestWithCache(t *testing.T) {
+	defer func(saved bool) { noCache, sessionCache = saved, nil }(noCache)
+
+	client := fake.New()
+
+	noCache = true
+	got, err := withCache(client, config.Config{})
+	if err != nil {
+		t.Fatalf("withCache() error = %v", err)
+	}
+	if got != pd.PagerDutyClient(client) || sessionCache != nil {
+		t.Errorf("withCache() with --no-cache = %T, want the client itself", got)
+	}
+
+	noCache = false
+	got, err = withCache(client, config.Config{})
+	if err != nil {
+		t.Fatalf("withCache() error = %v", err)
+	}
+	if _, ok := got.(*pd.CachingClient); !ok || sessionCache == nil {
+		t.Errorf("withCache() = %T, want a *pd.CachingClient", got)
+	}
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	config "github.com/aliceh/alertops/pkg/config"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/aliceh/alertops/pkg/silence"
+	"github.com/spf13/pflag"
+)
+
+func silencesPath() string {
+	// Demo silences refer to demo incidents, so keep them away from the real ones
+	if offlineDemo {
+		return filepath.Join(os.TempDir(), "alertops-demo-"+config.SilencesFileName)
+	}
+	return filepath.Join(os.ExpandEnv(config.Path), config.SilencesFileName)
+}
+
+// expireSilences lifts the timed silences that have expired since the last command that did so. Only
+// the commands already changing silences do it, so that listing incidents never writes to PagerDuty.
+// The command goes on if they cannot be lifted.
+func (s *session) expireSilences(ctx context.Context) {
+	store, err := silence.Load(silencesPath())
+	if err != nil {
+		fmt.Fprintf(stderr, "warning: %v\n", err)
+		return
+	}
+
+	lifted, err := store.Expire(ctx, s.PD.Client, s.PD.CurrentUser, time.Now())
+	for _, l := range lifted {
+		fmt.Fprintf(stderr, "%v silence expired\n", l.IncidentID)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "warning: failed to lift expired silences: %v\n", err)
+	}
+}
+
+func silenceCommand() *command {
+	var reason, cluster string
+	var window time.Duration
+	var wait bool
+
+	return &command{
+		name:  "silence",
+		usage: "alertops silence <incident-id>... | --cluster <cluster> [flags]",
+		short: "Acknowledge incidents and reassign them to the silent user",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&reason, "reason", "r", "", "why the incidents are being silenced, added to the note posted on them")
+			fs.DurationVar(&window, "for", 0, "hand the incidents back to their assignees once this long has passed; this happens on the next `silence` or `unsilence --expired` command, or in the dashboard, after it expires")
+			fs.BoolVar(&wait, "wait", false, "with --for, keep running and lift the silence when it expires")
+			clusterFlag(fs, &cluster)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := targetArgs(args, cluster); err != nil {
+				return err
+			}
+			if window < 0 {
+				return usageErrorf("--for must be positive")
+			}
+			if wait && window == 0 {
+				return usageErrorf("--wait requires --for")
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+			if s.PD.SilentUser == nil {
+				return fmt.Errorf("no silent user is configured")
+			}
+			s.expireSilences(ctx)
+
+			store, err := silence.Load(silencesPath())
+			if err != nil {
+				return err
+			}
+
+			incidents, err := s.targets(ctx, args, cluster)
+			if err != nil {
+				return err
+			}
+
+			var until time.Time
+			if window > 0 {
+				until = time.Now().Add(window)
+			}
+
+			result, err := pd.SilenceIncidentsWithContext(ctx, s.PD.Client, incidents, s.PD.CurrentUser, s.PD.SilentUser, reason, until)
+			if result == nil {
+				return err
+			}
+
+			// Results are in the order of the incidents; only record the silences PagerDuty applied
+			for i, r := range result.Results {
+				if r.Incident == nil {
+					fmt.Fprintf(stderr, "%v failed: %v\n", r.ID, r.Err)
+					continue
+				}
+
+				incident := incidents[i]
+				if until.IsZero() {
+					fmt.Fprintf(stdout, "%v silenced\n", incident.ID)
+				} else {
+					var assignees []string
+					for _, a := range incident.Assignments {
+						if a.Assignee.ID != s.PD.SilentUser.ID {
+							assignees = append(assignees, a.Assignee.ID)
+						}
+					}
+
+					store.Add(silence.Silence{
+						IncidentID:  incident.ID,
+						SilencedBy:  s.PD.CurrentUser.Name,
+						Reason:      reason,
+						Until:       until,
+						AssigneeIDs: assignees,
+					})
+					fmt.Fprintf(stdout, "%v silenced until %v\n", incident.ID, until.Format(time.RFC1123))
+				}
+
+				// The silence holds even if its note could not be posted
+				if r.Err != nil {
+					fmt.Fprintf(stderr, "%v failed: %v\n", r.ID, r.Err)
+				}
+			}
+
+			failure := resultsError(result)
+
+			if until.IsZero() || len(result.Applied()) == 0 {
+				return failure
+			}
+
+			if err := store.Save(); err != nil {
+				return err
+			}
+
+			if !wait {
+				return failure
+			}
+
+			select {
+			case <-time.After(time.Until(until)):
+			case <-ctx.Done():
+				return fmt.Errorf("stopped waiting, the silence will be lifted by the next `silence` or `unsilence --expired` command: %v", ctx.Err())
+			}
+
+			lifted, err := store.Expire(ctx, s.PD.Client, s.PD.CurrentUser, time.Now())
+			for _, l := range lifted {
+				fmt.Fprintf(stdout, "%v silence expired\n", l.IncidentID)
+			}
+			return errors.Join(failure, err)
+		},
+	}
+}
+
+func unsilenceCommand() *command {
+	var reason string
+	var expired bool
+
+	return &command{
+		name:  "unsilence",
+		usage: "alertops unsilence <incident-id>... | --expired",
+		short: "Hand silenced incidents back to their assignees",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&reason, "reason", "r", "", "why the silence is being lifted, added to the note posted on the incidents")
+			fs.BoolVar(&expired, "expired", false, "lift every timed silence that has expired")
+		},
+		run: func(ctx context.Context, args []string) error {
+			if expired == (len(args) > 0) {
+				return usageErrorf("expected either incident IDs or --expired")
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			store, err := silence.Load(silencesPath())
+			if err != nil {
+				return err
+			}
+
+			if expired {
+				lifted, err := store.Expire(ctx, s.PD.Client, s.PD.CurrentUser, time.Now())
+				for _, l := range lifted {
+					fmt.Fprintf(stdout, "%v silence expired\n", l.IncidentID)
+				}
+				return err
+			}
+
+			for _, id := range args {
+				// Incidents silenced without --for have no record, so they are re-escalated
+				sil, ok := store.Get(id)
+				if !ok {
+					sil = silence.Silence{IncidentID: id}
+				}
+
+				if err := silence.Lift(ctx, s.PD.Client, s.PD.CurrentUser, sil, reason); err != nil {
+					return err
+				}
+
+				store.Remove(id)
+				if err := store.Save(); err != nil {
+					return err
+				}
+				fmt.Fprintf(stdout, "%v unsilenced\n", id)
+			}
+
+			return nil
+		},
+	}
+}
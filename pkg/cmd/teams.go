@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/spf13/pflag"
+)
+
+func teamsCommand() *command {
+	var format output.Format
+
+	return &command{
+		name:  "teams",
+		usage: "alertops teams [flags]",
+		short: "List the configured teams and their members",
+		flags: func(fs *pflag.FlagSet) {
+			outputFlag(fs, &format)
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			var teams []pd.TeamSummary
+			for _, team := range s.PD.Teams {
+				members, err := pd.GetTeamMemberIDsWithContext(ctx, s.PD.Client, []*pagerduty.Team{team}, pagerduty.ListTeamMembersOptions{Limit: 100})
+				if err != nil {
+					return err
+				}
+				teams = append(teams, pd.NewTeamSummary(team, members))
+			}
+
+			return output.Print(stdout, format, teams, teamColumns)
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	ui "github.com/aliceh/alertops/pkg/ui"
+	utils "github.com/aliceh/alertops/pkg/utils"
+	"github.com/spf13/pflag"
+)
+
+func uiCommand() *command {
+	var refresh time.Duration
+	var logFile string
+
+	return &command{
+		name:  "ui",
+		usage: "alertops ui [flags]",
+		short: "Open the interactive incident dashboard",
+		// The dashboard runs until the user quits it
+		noTimeout: true,
+		flags: func(fs *pflag.FlagSet) {
+			fs.DurationVar(&refresh, "refresh", time.Minute, "how often to reload incidents, 0 to disable")
+			fs.StringVar(&logFile, "log-file", "", "file to write logs to while the dashboard is open")
+		},
+		run: func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+
+			// The dashboard owns the terminal, so logs must not go to stderr
+			var w io.Writer = io.Discard
+			if logFile != "" {
+				f, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+				if err != nil {
+					return fmt.Errorf("failed to open log file: %v", err)
+				}
+				defer f.Close()
+				w = f
+			}
+			utils.InitLogger(w)
+			debugOutput = w
+
+			s, err := newSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			return ui.NewDashboard(s.PD, s.teamUsers(), refresh, silencesPath()).Run(ctx)
+		},
+	}
+}
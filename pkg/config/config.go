@@ -1,7 +1,7 @@
 package config
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,6 +11,9 @@ const (
 	ConfigFileType = "yaml"
 	Path           = "$HOME/.config/srepd"
 	PathOsdctl     = "$HOME/.config/"
+
+	// SilencesFileName is the file in Path where timed silences are kept until they expire
+	SilencesFileName = "silences.json"
 )
 
 type Config struct {
@@ -20,6 +23,57 @@ type Config struct {
 	IgnoredUsers []string
 	ApiKey       string `json:"api_key,omitempty"`
 	AccessToken  string `json:"gh_token,omitempty"`
+	// APIURL overrides the PagerDuty API endpoint, e.g. to point at a local emulator
+	APIURL string `json:"apiurl,omitempty"`
+	// CacheTTL is how long services, users and teams are cached, DiskCache keeps them between runs
+	CacheTTL  time.Duration `json:"cachettl,omitempty"`
+	DiskCache bool          `json:"diskcache,omitempty"`
+	// MaxRetries is how many times throttled or failed PagerDuty calls are retried, 0 to never retry
+	// them. Nil if unset, to use the default.
+	MaxRetries *int `json:"maxretries,omitempty"`
+	// ClusterNames configure how cluster names are resolved for the alerts of each team
+	ClusterNames []ClusterNameChain `json:"clusternames,omitempty"`
+	// AlertMappings describe alert shapes not covered by the built-in alert parsers
+	AlertMappings []AlertMapping `json:"alertmappings,omitempty"`
+}
+
+// AlertMapping maps the details of the alerts matching all of its Match rules onto alert fields.
+//
+//	alertmappings:
+//	  - name: cloudwatch
+//	    match:
+//	      - key: AlarmName
+//	      - key: Region
+//	        value: ^us-
+//	    fields:
+//	      cluster_name: {path: $.Trigger.Dimensions[0].value}
+//	      sop: {path: AlarmDescription, regex: 'runbook: (\S+)'}
+type AlertMapping struct {
+	Name   string             `mapstructure:"name"`
+	Match  []AlertMatch       `mapstructure:"match"`
+	Fields AlertMappingFields `mapstructure:"fields"`
+}
+
+// AlertMatch requires the alert details to contain Key and, if Value is set, its value to match the
+// Value regular expression.
+type AlertMatch struct {
+	Key   string `mapstructure:"key"`
+	Value string `mapstructure:"value"`
+}
+
+type AlertMappingFields struct {
+	ClusterID   *Extraction `mapstructure:"cluster_id"`
+	ClusterName *Extraction `mapstructure:"cluster_name"`
+	Sop         *Extraction `mapstructure:"sop"`
+	Severity    *Extraction `mapstructure:"severity"`
+	Labels      *Extraction `mapstructure:"labels"`
+}
+
+// Extraction reads the value at the JSONPath-style Path in the alert details. If Regex is set, the
+// value becomes its first capture group, or the whole match if it has none.
+type Extraction struct {
+	Path  string `mapstructure:"path"`
+	Regex string `mapstructure:"regex"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -29,13 +83,64 @@ func LoadConfig(path string) (config Config, err error) {
 
 	err = viper.ReadInConfig()
 	if err != nil {
-		fmt.Println(err)
 		return config, err
 	}
 	config.Token = viper.GetString("token")
 	config.Teams = viper.GetStringSlice("teams")
 	config.SilentUser = viper.GetString("silentuser")
 	config.IgnoredUsers = viper.GetStringSlice("ignoredusers")
+	config.AccessToken = viper.GetString("gh_token")
+	config.APIURL = viper.GetString("apiurl")
+	config.CacheTTL = viper.GetDuration("cachettl")
+	config.DiskCache = viper.GetBool("diskcache")
+	if viper.IsSet("maxretries") {
+		maxRetries := viper.GetInt("maxretries")
+		config.MaxRetries = &maxRetries
+	}
+
+	err = viper.UnmarshalKey("alertmappings", &config.AlertMappings)
+	if err != nil {
+		return config, err
+	}
+
+	err = viper.UnmarshalKey("clusternames", &config.ClusterNames)
+	if err != nil {
+		return config, err
+	}
 
 	return config, nil
 }
+
+// ClusterNameChain is the list of resolvers tried in order to name the cluster of an alert, until one
+// of them finds a name. It applies to the alerts of services owned by one of Teams, or to every alert
+// not covered by another chain if Teams is empty.
+//
+//	clusternames:
+//	  - teams: [PXXXXXX]
+//	    resolvers:
+//	      - {type: alert_detail, key: name, regex: '^([^.]+)'}
+//	      - {type: service_description, regex: '^(\S+) '}
+//	      - {type: service_name, regex: '^(.+)-hive-cluster$'}
+//	      - {type: mapping_file, path: $HOME/.config/srepd/clusters.yaml}
+//	      - {type: command, command: [ocm-cluster-name], timeout: 5s}
+type ClusterNameChain struct {
+	Teams     []string              `mapstructure:"teams"`
+	Resolvers []ClusterNameResolver `mapstructure:"resolvers"`
+}
+
+// ClusterNameResolver is one way of naming a cluster. Depending on Type:
+//   - service_description and service_name match Regex against the alert service
+//   - alert_detail reads the JSONPath-style Key of the alert details, optionally matched against Regex
+//   - mapping_file looks the cluster ID up in the YAML or JSON map of cluster IDs to names at Path
+//   - command runs Command with the ALERTOPS_CLUSTER_ID, ALERTOPS_ALERT_ID and ALERTOPS_SERVICE_ID
+//     environment variables set, and uses the first line it prints
+//
+// With a Regex, the name is its first capture group, or the whole match if it has none.
+type ClusterNameResolver struct {
+	Type    string        `mapstructure:"type"`
+	Regex   string        `mapstructure:"regex"`
+	Key     string        `mapstructure:"key"`
+	Path    string        `mapstructure:"path"`
+	Command []string      `mapstructure:"command"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
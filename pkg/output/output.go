@@ -0,0 +1,212 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format listings are printed in. It implements pflag.Value so it can be
+// used directly as a command line flag.
+type Format string
+
+const (
+	Table Format = "table"
+	Wide  Format = "wide"
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	CSV   Format = "csv"
+)
+
+var Formats = []Format{Table, Wide, JSON, YAML, CSV}
+
+// Column describes how one field of T is shown in table, wide and CSV output. JSON and YAML
+// output use the json tags of T instead.
+type Column[T any] struct {
+	Header string
+
+	// Wide columns are only shown in wide and CSV output
+	Wide bool
+
+	Value func(T) string
+}
+
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format `%v`, must be one of %v", s, Formats)
+}
+
+func (f *Format) String() string {
+	return string(*f)
+}
+
+func (f *Format) Set(s string) error {
+	format, err := ParseFormat(s)
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
+
+func (f *Format) Type() string {
+	return "format"
+}
+
+// Print writes items to w as a list in the given format.
+func Print[T any](w io.Writer, format Format, items []T, columns []Column[T]) error {
+	// Always print an empty list rather than null so consumers don't have to special case it
+	if items == nil {
+		items = []T{}
+	}
+
+	switch format {
+	case JSON:
+		return printJSON(w, items)
+	case YAML:
+		return printYAML(w, items)
+	case CSV:
+		return printCSV(w, items, columns)
+	case Wide:
+		return printTable(w, items, columns)
+	default:
+		return printTable(w, items, narrow(columns))
+	}
+}
+
+// PrintDetail writes a single item to w in the given format. Table and wide output show one
+// "Header: value" line per column rather than a single row.
+func PrintDetail[T any](w io.Writer, format Format, item T, columns []Column[T]) error {
+	switch format {
+	case JSON:
+		return printJSON(w, item)
+	case YAML:
+		return printYAML(w, item)
+	case CSV:
+		return printCSV(w, []T{item}, columns)
+	case Table:
+		columns = narrow(columns)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range columns {
+		fmt.Fprintf(tw, "%v:\t%v\n", title(c.Header), c.Value(item))
+	}
+	return tw.Flush()
+}
+
+func printJSON(w io.Writer, v any) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(v)
+}
+
+// printYAML goes through JSON so that types without yaml tags, such as the go-pagerduty ones,
+// are shown with the same field names as in JSON output
+func printYAML(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	plainStyle(&node)
+
+	e := yaml.NewEncoder(w)
+	e.SetIndent(2)
+	if err := e.Encode(&node); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// plainStyle drops the JSON flow and quoting styles so the output reads like regular YAML
+func plainStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		plainStyle(c)
+	}
+}
+
+func printCSV[T any](w io.Writer, items []T, columns []Column[T]) error {
+	cw := csv.NewWriter(w)
+
+	var headers []string
+	for _, c := range columns {
+		headers = append(headers, c.Header)
+	}
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var row []string
+		for _, c := range columns {
+			row = append(row, c.Value(item))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func printTable[T any](w io.Writer, items []T, columns []Column[T]) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	var headers []string
+	for _, c := range columns {
+		headers = append(headers, c.Header)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, item := range items {
+		var row []string
+		for _, c := range columns {
+			// Tabs and newlines would break the table layout
+			row = append(row, strings.NewReplacer("\t", " ", "\n", " ").Replace(c.Value(item)))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// narrow drops the columns that are only shown in wide output
+func narrow[T any](columns []Column[T]) []Column[T] {
+	var n []Column[T]
+	for _, c := range columns {
+		if !c.Wide {
+			n = append(n, c)
+		}
+	}
+	return n
+}
+
+// title turns a column header such as "CLUSTER ID" into a label such as "Cluster ID"
+func title(header string) string {
+	words := strings.Fields(strings.ToLower(header))
+	for i, word := range words {
+		switch word {
+		case "id", "url", "ip", "sop":
+			words[i] = strings.ToUpper(word)
+		default:
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
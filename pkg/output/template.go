@@ -0,0 +1,148 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	utils "github.com/aliceh/alertops/pkg/utils"
+)
+
+var colors = map[string]string{
+	"red":     "\033[31m",
+	"green":   "\033[32m",
+	"yellow":  "\033[33m",
+	"blue":    "\033[34m",
+	"magenta": "\033[35m",
+	"cyan":    "\033[36m",
+	"gray":    "\033[90m",
+	"bold":    "\033[1m",
+}
+
+const colorReset = "\033[0m"
+
+// severityColors are the colors severities are shown in, in templates and in the dashboard
+var severityColors = map[string]string{
+	"critical": "red",
+	"error":    "magenta",
+	"warning":  "yellow",
+	"info":     "cyan",
+}
+
+// SeverityColor returns the name of the color a normalised severity is shown in, or "" if it has none.
+func SeverityColor(severity string) string {
+	return severityColors[severity]
+}
+
+// TemplateFuncs are the helper functions available to user templates, e.g.
+//
+//	{{.ID}} {{.Status | color "red"}} {{.CreatedAt | since}} {{.Title | truncate 40}}
+var TemplateFuncs = template.FuncMap{
+	"timestamp": utils.FormatTimestamp,
+	"since":     since,
+	"truncate":  truncate,
+	"color":     color,
+	"severity":  severityColor,
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"join":      strings.Join,
+}
+
+// ParseTemplate parses a user template with TemplateFuncs available to it.
+func ParseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("output").Funcs(TemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return t, nil
+}
+
+// PrintTemplate renders t once per item, ending each rendering with a newline unless the
+// template already provides one.
+func PrintTemplate[T any](w io.Writer, t *template.Template, items []T) error {
+	for _, item := range items {
+		var b bytes.Buffer
+		if err := t.Execute(&b, item); err != nil {
+			return fmt.Errorf("failed to render template: %v", err)
+		}
+
+		if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+			b.WriteByte('\n')
+		}
+
+		if _, err := w.Write(b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// since returns how long ago a PagerDuty timestamp was, e.g. "3d4h" or "12m"
+func since(timestamp string) (string, error) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return "", err
+	}
+	return FormatDuration(time.Since(t)), nil
+}
+
+// FormatDuration formats d with its two most significant units, e.g. "3d4h", "2h13m" or "45s".
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d < 0 {
+		d = -d
+	}
+
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	seconds := (d % time.Minute) / time.Second
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+// truncate shortens s to at most n characters, marking the cut with an ellipsis
+func truncate(n int, s string) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("invalid length `%v`, must not be negative", n)
+	}
+
+	r := []rune(s)
+	if len(r) <= n {
+		return s, nil
+	}
+	if n <= 1 {
+		return string(r[:n]), nil
+	}
+	return string(r[:n-1]) + "…", nil
+}
+
+// color wraps s in the ANSI escape codes for the named color
+func color(name, s string) (string, error) {
+	code, ok := colors[name]
+	if !ok {
+		return "", fmt.Errorf("unknown color `%v`", name)
+	}
+	return code + s + colorReset, nil
+}
+
+// severityColor colors a severity according to SeverityColor, leaving unknown severities as they are
+func severityColor(severity string) string {
+	code, ok := colors[SeverityColor(severity)]
+	if !ok {
+		return severity
+	}
+	return code + severity + colorReset
+}
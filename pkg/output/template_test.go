@@ -0,0 +1,43 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		n       int
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{n: 10, s: "etcdMembersDown", want: "etcdMembe…"},
+		{n: 15, s: "etcdMembersDown", want: "etcdMembersDown"},
+		{n: 3, s: "démarré", want: "dé…"},
+		{n: 1, s: "etcdMembersDown", want: "e"},
+		{n: 0, s: "etcdMembersDown", want: ""},
+		{n: -1, s: "etcdMembersDown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := truncate(tt.n, tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("truncate(%v, %q) = %q, %v, want %q, error %v", tt.n, tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPrintTemplateNegativeTruncate(t *testing.T) {
+	tmpl, err := ParseTemplate("{{.Title | truncate -5}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	var b bytes.Buffer
+	err = PrintTemplate(&b, tmpl, []struct{ Title string }{{Title: "etcdMembersDown"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid length `-5`, must not be negative") {
+		t.Errorf("PrintTemplate() error = %v, want the invalid length reported", err)
+	}
+}
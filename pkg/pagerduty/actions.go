@@ -0,0 +1,314 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// ResolveIncidents calls ResolveIncidentsWithContext with a background context.
+func ResolveIncidents(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User) (*BulkResult, error) {
+	return ResolveIncidentsWithContext(context.Background(), client, incidents, user)
+}
+
+// ResolveIncidentsWithContext resolves the incidents on behalf of user. The result reports the
+// outcome for each incident; the error lists those it failed on.
+func ResolveIncidentsWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User) (*BulkResult, error) {
+	opts, err := incidentOptions(incidents, func(o *pagerduty.ManageIncidentsOptions) {
+		o.Status = "resolved"
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.ResolveIncidents(): %v", err)
+	}
+
+	result := BulkManageIncidentsWithContext(ctx, client, user.Email, opts)
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.ResolveIncidents(): failed to resolve incident(s): %v", err)
+	}
+
+	return result, nil
+}
+
+// SnoozeIncidents calls SnoozeIncidentsWithContext with a background context.
+func SnoozeIncidents(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, duration time.Duration) (*BulkResult, error) {
+	return SnoozeIncidentsWithContext(context.Background(), client, incidents, user, duration)
+}
+
+// SnoozeIncidentsWithContext snoozes the incidents on behalf of user for duration, rounded to the
+// second, after which PagerDuty triggers them again. Only acknowledged incidents can be snoozed, the
+// others fail.
+func SnoozeIncidentsWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, duration time.Duration) (*BulkResult, error) {
+	seconds := duration.Round(time.Second) / time.Second
+	if seconds <= 0 {
+		return nil, fmt.Errorf("pd.SnoozeIncidents(): snooze duration must be at least a second, got %v", duration)
+	}
+
+	result, err := eachIncident(ctx, incidents, func(incident *pagerduty.Incident) (*pagerduty.Incident, error) {
+		if incident.Status != "acknowledged" {
+			return nil, fmt.Errorf("incident is %v, only acknowledged incidents can be snoozed", incident.Status)
+		}
+		return client.SnoozeIncidentWithContext(ctx, user.Email, incident.ID, uint(seconds))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.SnoozeIncidents(): %v", err)
+	}
+
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.SnoozeIncidents(): failed to snooze incident(s): %v", err)
+	}
+
+	return result, nil
+}
+
+// SetUrgency calls SetUrgencyWithContext with a background context.
+func SetUrgency(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, urgency string) (*BulkResult, error) {
+	return SetUrgencyWithContext(context.Background(), client, incidents, user, urgency)
+}
+
+// SetUrgencyWithContext sets the urgency of the incidents, high or low, on behalf of user.
+func SetUrgencyWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, urgency string) (*BulkResult, error) {
+	if urgency != "high" && urgency != "low" {
+		return nil, fmt.Errorf("pd.SetUrgency(): urgency must be high or low, got `%v`", urgency)
+	}
+
+	result, err := eachIncident(ctx, incidents, func(incident *pagerduty.Incident) (*pagerduty.Incident, error) {
+		return client.UpdateIncidentUrgencyWithContext(ctx, user.Email, incident.ID, urgency)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.SetUrgency(): %v", err)
+	}
+
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.SetUrgency(): failed to set the urgency of incident(s): %v", err)
+	}
+
+	return result, nil
+}
+
+// GetPriorities calls GetPrioritiesWithContext with a background context.
+func GetPriorities(client PagerDutyClient) ([]pagerduty.Priority, error) {
+	return GetPrioritiesWithContext(context.Background(), client)
+}
+
+// GetPrioritiesWithContext returns the priorities of the account, highest first. It is empty if
+// priorities are disabled.
+func GetPrioritiesWithContext(ctx context.Context, client PagerDutyClient) ([]pagerduty.Priority, error) {
+	var priorities []pagerduty.Priority
+
+	opts := pagerduty.ListPrioritiesOptions{Limit: defaultPageLimit, Offset: defaultOffset}
+
+	for {
+		response, err := client.ListPrioritiesWithContext(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("pd.GetPriorities(): failed to list priorities: %v", err)
+		}
+
+		priorities = append(priorities, response.Priorities...)
+
+		if !response.More {
+			break
+		}
+		opts.Offset += opts.Limit
+	}
+
+	return priorities, nil
+}
+
+// SetPriority calls SetPriorityWithContext with a background context.
+func SetPriority(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, priority string) (*BulkResult, error) {
+	return SetPriorityWithContext(context.Background(), client, incidents, user, priority)
+}
+
+// SetPriorityWithContext sets the priority of the incidents on behalf of user. The priority is
+// given by ID or by name, e.g. P1, ignoring case.
+func SetPriorityWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, priority string) (*BulkResult, error) {
+	priorities, err := GetPrioritiesWithContext(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("pd.SetPriority(): %v", err)
+	}
+
+	var found *pagerduty.Priority
+	var names []string
+	for i, p := range priorities {
+		if p.ID == priority || strings.EqualFold(p.Name, priority) {
+			found = &priorities[i]
+		}
+		names = append(names, p.Name)
+	}
+	if len(priorities) == 0 {
+		return nil, fmt.Errorf("pd.SetPriority(): priorities are disabled on this PagerDuty account")
+	}
+	if found == nil {
+		return nil, fmt.Errorf("pd.SetPriority(): no priority `%v`, expected one of %v", priority, strings.Join(names, ", "))
+	}
+
+	opts, err := incidentOptions(incidents, func(o *pagerduty.ManageIncidentsOptions) {
+		o.Priority = &pagerduty.APIReference{ID: found.ID, Type: "priority_reference"}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.SetPriority(): %v", err)
+	}
+
+	result := BulkManageIncidentsWithContext(ctx, client, user.Email, opts)
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.SetPriority(): failed to set the priority of incident(s) to %v: %v", found.Name, err)
+	}
+
+	return result, nil
+}
+
+// EscalateIncidents calls EscalateIncidentsWithContext with a background context.
+func EscalateIncidents(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User) (*BulkResult, error) {
+	return EscalateIncidentsWithContext(context.Background(), client, incidents, user)
+}
+
+// EscalateIncidentsWithContext escalates each incident to the next level of its escalation policy on
+// behalf of user. PagerDuty does not report the level an incident is at, so it is taken to be the
+// highest level its assignees are on call at, or the first level if none of them is. Incidents
+// already at the last level fail.
+func EscalateIncidentsWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User) (*BulkResult, error) {
+	result := &BulkResult{}
+	levels := map[string][]pagerduty.OnCall{}
+	var opts []pagerduty.ManageIncidentsOptions
+
+	for _, incident := range incidents {
+		if incident == nil {
+			return nil, fmt.Errorf("pd.EscalateIncidents(): incident is nil")
+		}
+		result.Results = append(result.Results, IncidentResult{ID: incident.ID})
+
+		policy := incident.EscalationPolicy.ID
+		if policy == "" {
+			result.fail(incident.ID, fmt.Errorf("incident has no escalation policy"))
+			continue
+		}
+
+		oncalls, ok := levels[policy]
+		if !ok {
+			var err error
+			oncalls, err = getOnCalls(ctx, client, policy)
+			if err != nil {
+				return nil, fmt.Errorf("pd.EscalateIncidents(): failed to list the on-calls of escalation policy `%v`: %v", policy, err)
+			}
+			levels[policy] = oncalls
+		}
+
+		next, err := nextEscalationLevel(incident, oncalls)
+		if err != nil {
+			result.fail(incident.ID, err)
+			continue
+		}
+
+		opts = append(opts, pagerduty.ManageIncidentsOptions{ID: incident.ID, EscalationLevel: next})
+	}
+
+	result.merge(BulkManageIncidentsWithContext(ctx, client, user.Email, opts))
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.EscalateIncidents(): failed to escalate incident(s): %v", err)
+	}
+
+	return result, nil
+}
+
+// PostNotes calls PostNotesWithContext with a background context.
+func PostNotes(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, content string) (*BulkResult, error) {
+	return PostNotesWithContext(context.Background(), client, incidents, user, content)
+}
+
+// PostNotesWithContext adds the same note to each incident on behalf of user. A note is not a change
+// to the incident, so the result holds the incidents as given for those the note was added to.
+func PostNotesWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, content string) (*BulkResult, error) {
+	result, err := eachIncident(ctx, incidents, func(incident *pagerduty.Incident) (*pagerduty.Incident, error) {
+		if _, err := PostNoteWithContext(ctx, client, incident.ID, user, content); err != nil {
+			return nil, err
+		}
+		return incident, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.PostNotes(): %v", err)
+	}
+
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.PostNotes(): failed to add note to incident(s): %v", err)
+	}
+
+	return result, nil
+}
+
+// getOnCalls returns the on-call entries of an escalation policy, at every level
+func getOnCalls(ctx context.Context, client PagerDutyClient, policy string) ([]pagerduty.OnCall, error) {
+	var oncalls []pagerduty.OnCall
+
+	opts := pagerduty.ListOnCallOptions{Limit: defaultPageLimit, Offset: defaultOffset, EscalationPolicyIDs: []string{policy}}
+
+	for {
+		response, err := client.ListOnCallsWithContext(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		oncalls = append(oncalls, response.OnCalls...)
+
+		if !response.More {
+			break
+		}
+		opts.Offset += opts.Limit
+	}
+
+	return oncalls, nil
+}
+
+// nextEscalationLevel returns the lowest level with an on-call above the one the incident is at
+func nextEscalationLevel(incident *pagerduty.Incident, oncalls []pagerduty.OnCall) (uint, error) {
+	var current uint = 1
+	for _, o := range oncalls {
+		for _, a := range incident.Assignments {
+			if a.Assignee.ID == o.User.ID && o.EscalationLevel > current {
+				current = o.EscalationLevel
+			}
+		}
+	}
+
+	var next uint
+	for _, o := range oncalls {
+		if o.EscalationLevel > current && (next == 0 || o.EscalationLevel < next) {
+			next = o.EscalationLevel
+		}
+	}
+
+	if next == 0 {
+		return 0, fmt.Errorf("already at the last level (%v) of escalation policy `%v`", current, incident.EscalationPolicy.ID)
+	}
+	return next, nil
+}
+
+// eachIncident applies a change to the incidents one at a time, for the changes PagerDuty only
+// accepts on a single incident, and never stops early unless ctx is done
+func eachIncident(ctx context.Context, incidents []*pagerduty.Incident, change func(incident *pagerduty.Incident) (*pagerduty.Incident, error)) (*BulkResult, error) {
+	result := &BulkResult{}
+	for _, incident := range incidents {
+		if incident == nil {
+			return nil, fmt.Errorf("incident is nil")
+		}
+		result.Results = append(result.Results, IncidentResult{ID: incident.ID})
+	}
+
+	for i, incident := range incidents {
+		if err := ctx.Err(); err != nil {
+			result.Results[i].Err = err
+			continue
+		}
+
+		updated, err := change(incident)
+		if err != nil {
+			result.Results[i].Err = err
+			continue
+		}
+		result.Results[i].Incident = updated
+	}
+
+	return result, nil
+}
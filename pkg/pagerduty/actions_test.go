@@ -0,0 +1,267 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+func oncall(userID string, level uint) pagerduty.OnCall {
+	return pagerduty.OnCall{
+		User:             pagerduty.User{APIObject: pagerduty.APIObject{ID: userID}},
+		EscalationPolicy: pagerduty.EscalationPolicy{APIObject: pagerduty.APIObject{ID: "PPOL1"}},
+		EscalationLevel:  level,
+	}
+}
+
+func assignedTo(ids ...string) []pagerduty.Assignment {
+	var assignments []pagerduty.Assignment
+	for _, id := range ids {
+		assignments = append(assignments, pagerduty.Assignment{Assignee: pagerduty.APIObject{ID: id}})
+	}
+	return assignments
+}
+
+func TestNextEscalationLevel(t *testing.T) {
+	oncalls := []pagerduty.OnCall{oncall("PUSER1", 1), oncall("PUSER2", 2), oncall("PUSER3", 4), oncall("PUSER4", 4)}
+
+	tests := []struct {
+		name      string
+		assignees []string
+		oncalls   []pagerduty.OnCall
+		want      uint
+		wantErr   bool
+	}{
+		{name: "first level", assignees: []string{"PUSER1"}, oncalls: oncalls, want: 2},
+		{name: "skips levels without on-calls", assignees: []string{"PUSER2"}, oncalls: oncalls, want: 4},
+		{name: "assignee not on call is taken to be at the first level", assignees: []string{"PUSER9"}, oncalls: oncalls, want: 2},
+		{name: "no assignees", oncalls: oncalls, want: 2},
+		{name: "assignees on different levels", assignees: []string{"PUSER1", "PUSER2"}, oncalls: oncalls, want: 4},
+		{name: "last level", assignees: []string{"PUSER3"}, oncalls: oncalls, wantErr: true},
+		{name: "last level with an assignee lower down", assignees: []string{"PUSER1", "PUSER4"}, oncalls: oncalls, wantErr: true},
+		{name: "single level policy", assignees: []string{"PUSER1"}, oncalls: oncalls[:1], wantErr: true},
+		{name: "no on-calls", assignees: []string{"PUSER1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incident := &pagerduty.Incident{
+				APIObject:        pagerduty.APIObject{ID: "PINC1"},
+				EscalationPolicy: pagerduty.APIObject{ID: "PPOL1"},
+				Assignments:      assignedTo(tt.assignees...),
+			}
+
+			got, err := nextEscalationLevel(incident, tt.oncalls)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("nextEscalationLevel() = %v, %v, want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// newActionsClient returns a client with an escalation policy of three levels, PUSER1 to PUSER3, and
+// incidents assigned at each of them
+func newActionsClient() *fake.Client {
+	c := fake.New()
+	c.Now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	for i, email := range []string{"jane@example.com", "john@example.com", "joan@example.com"} {
+		id := fmt.Sprintf("PUSER%v", i+1)
+		c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: id}, Name: id, Email: email})
+		c.AddOnCall(oncall(id, uint(i+1)))
+	}
+	c.SetCurrentUser("PUSER1")
+
+	c.AddPriority(pagerduty.Priority{APIObject: pagerduty.APIObject{ID: "PPRI1"}, Name: "P1"})
+	c.AddPriority(pagerduty.Priority{APIObject: pagerduty.APIObject{ID: "PPRI2"}, Name: "P2"})
+
+	policy := pagerduty.APIObject{ID: "PPOL1"}
+	c.AddIncident(pagerduty.Incident{APIObject: pagerduty.APIObject{ID: "PINC1"}, Status: "triggered", Urgency: "high", EscalationPolicy: policy, Assignments: assignedTo("PUSER1")})
+	c.AddIncident(pagerduty.Incident{APIObject: pagerduty.APIObject{ID: "PINC2"}, Status: "acknowledged", Urgency: "high", EscalationPolicy: policy, Assignments: assignedTo("PUSER2")})
+	c.AddIncident(pagerduty.Incident{APIObject: pagerduty.APIObject{ID: "PINC3"}, Status: "acknowledged", Urgency: "high", EscalationPolicy: policy, Assignments: assignedTo("PUSER3")})
+	c.AddIncident(pagerduty.Incident{APIObject: pagerduty.APIObject{ID: "PINC4"}, Status: "triggered", Urgency: "high"})
+
+	return c
+}
+
+var jane = &pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER1"}, Email: "jane@example.com"}
+
+func getIncidents(t *testing.T, c PagerDutyClient, ids ...string) []*pagerduty.Incident {
+	t.Helper()
+
+	var incidents []*pagerduty.Incident
+	for _, id := range ids {
+		incident, err := GetIncidentWithContext(context.Background(), c, id)
+		if err != nil {
+			t.Fatalf("GetIncident(%v) error = %v", id, err)
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents
+}
+
+// checkResult checks the result has applied the change to the applied incidents only
+func checkResult(t *testing.T, result *BulkResult, err error, applied, failed []string) {
+	t.Helper()
+
+	if result == nil {
+		t.Fatalf("no result, error = %v", err)
+	}
+	if (err != nil) != (len(failed) > 0) {
+		t.Errorf("error = %v, want failures %v", err, failed)
+	}
+
+	var gotApplied, gotFailed []string
+	for _, r := range result.Results {
+		if r.Err != nil {
+			gotFailed = append(gotFailed, r.ID)
+		} else if r.Incident != nil {
+			gotApplied = append(gotApplied, r.ID)
+		}
+	}
+	if strings.Join(gotApplied, ",") != strings.Join(applied, ",") || strings.Join(gotFailed, ",") != strings.Join(failed, ",") {
+		t.Errorf("applied %v and failed %v, want %v and %v", gotApplied, gotFailed, applied, failed)
+	}
+}
+
+func TestResolveIncidents(t *testing.T) {
+	c := newActionsClient()
+
+	result, err := ResolveIncidentsWithContext(context.Background(), c, getIncidents(t, c, "PINC1", "PINC2"), jane)
+	checkResult(t, result, err, []string{"PINC1", "PINC2"}, nil)
+
+	for _, incident := range getIncidents(t, c, "PINC1", "PINC2") {
+		if incident.Status != "resolved" {
+			t.Errorf("%v is %v, want resolved", incident.ID, incident.Status)
+		}
+	}
+}
+
+func TestSnoozeIncidents(t *testing.T) {
+	c := newActionsClient()
+	srv := fake.NewServer(c)
+	defer srv.Close()
+
+	// Through the API, so that a missing From header would be rejected
+	client := NewClient("test-token", srv.URL)
+
+	result, err := SnoozeIncidentsWithContext(context.Background(), client, getIncidents(t, client, "PINC1", "PINC2"), jane, 90*time.Minute)
+	checkResult(t, result, err, []string{"PINC2"}, []string{"PINC1"})
+	if err == nil || !strings.Contains(err.Error(), "only acknowledged incidents can be snoozed") {
+		t.Errorf("error = %v, want PINC1 refused as triggered", err)
+	}
+
+	snoozed := getIncidents(t, client, "PINC2")[0]
+	if len(snoozed.PendingActions) != 1 || snoozed.PendingActions[0].Type != "unacknowledge" || snoozed.PendingActions[0].At != "2024-05-01T13:30:00Z" {
+		t.Errorf("pending actions = %+v, want an unacknowledge at 13:30", snoozed.PendingActions)
+	}
+
+	unknown := &pagerduty.User{Email: "nobody@example.com"}
+	if _, err := SnoozeIncidentsWithContext(context.Background(), client, getIncidents(t, client, "PINC3"), unknown, time.Hour); err == nil {
+		t.Errorf("snoozing on behalf of an unknown user succeeded")
+	}
+
+	if _, err := SnoozeIncidentsWithContext(context.Background(), client, getIncidents(t, client, "PINC3"), jane, 0); err == nil {
+		t.Errorf("snoozing for no time succeeded")
+	}
+}
+
+func TestEscalateIncidents(t *testing.T) {
+	c := newActionsClient()
+
+	result, err := EscalateIncidentsWithContext(context.Background(), c, getIncidents(t, c, "PINC1", "PINC2", "PINC3", "PINC4"), jane)
+	checkResult(t, result, err, []string{"PINC1", "PINC2"}, []string{"PINC3", "PINC4"})
+	if err == nil || !strings.Contains(err.Error(), "already at the last level (3)") || !strings.Contains(err.Error(), "no escalation policy") {
+		t.Errorf("error = %v, want PINC3 at the last level and PINC4 without a policy", err)
+	}
+
+	for id, want := range map[string]string{"PINC1": "PUSER2", "PINC2": "PUSER3", "PINC3": "PUSER3"} {
+		incident := getIncidents(t, c, id)[0]
+		if len(incident.Assignments) != 1 || incident.Assignments[0].Assignee.ID != want {
+			t.Errorf("%v is assigned to %+v, want %v", id, incident.Assignments, want)
+		}
+	}
+}
+
+func TestSetUrgency(t *testing.T) {
+	c := newActionsClient()
+
+	result, err := SetUrgencyWithContext(context.Background(), c, getIncidents(t, c, "PINC1", "PINC2"), jane, "low")
+	checkResult(t, result, err, []string{"PINC1", "PINC2"}, nil)
+	for _, incident := range getIncidents(t, c, "PINC1", "PINC2") {
+		if incident.Urgency != "low" {
+			t.Errorf("%v urgency is %v, want low", incident.ID, incident.Urgency)
+		}
+	}
+
+	if _, err := SetUrgencyWithContext(context.Background(), c, getIncidents(t, c, "PINC1"), jane, "medium"); err == nil {
+		t.Errorf("SetUrgency() to medium succeeded")
+	}
+}
+
+func TestSetPriority(t *testing.T) {
+	tests := []struct {
+		name         string
+		priority     string
+		noPriorities bool
+		want         string
+		wantErr      string
+	}{
+		{name: "by name ignoring case", priority: "p2", want: "PPRI2"},
+		{name: "by ID", priority: "PPRI1", want: "PPRI1"},
+		{name: "unknown priority", priority: "P9", wantErr: "no priority `P9`, expected one of P1, P2"},
+		{name: "priorities disabled", priority: "P1", noPriorities: true, wantErr: "priorities are disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newActionsClient()
+			if tt.noPriorities {
+				c = fake.New()
+				c.AddIncident(pagerduty.Incident{APIObject: pagerduty.APIObject{ID: "PINC1"}, Status: "triggered"})
+			}
+
+			result, err := SetPriorityWithContext(context.Background(), c, getIncidents(t, c, "PINC1"), jane, tt.priority)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("SetPriority() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			checkResult(t, result, err, []string{"PINC1"}, nil)
+			if incident := getIncidents(t, c, "PINC1")[0]; incident.Priority == nil || incident.Priority.ID != tt.want {
+				t.Errorf("priority = %+v, want %v", incident.Priority, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostNotes(t *testing.T) {
+	c := newActionsClient()
+
+	// Deleted since it was listed, so the note cannot be added to it
+	incidents := append(getIncidents(t, c, "PINC1"), &pagerduty.Incident{APIObject: pagerduty.APIObject{ID: "PGONE"}})
+	incidents = append(incidents, getIncidents(t, c, "PINC2")...)
+
+	result, err := PostNotesWithContext(context.Background(), c, incidents, jane, "Looking into it")
+	checkResult(t, result, err, []string{"PINC1", "PINC2"}, []string{"PGONE"})
+	if err == nil || !strings.Contains(err.Error(), "PGONE") {
+		t.Errorf("error = %v, want PGONE reported", err)
+	}
+
+	for _, id := range []string{"PINC1", "PINC2"} {
+		notes, err := GetNotesWithContext(context.Background(), c, id)
+		if err != nil {
+			t.Fatalf("GetNotes(%v) error = %v", id, err)
+		}
+		if len(notes) != 1 || notes[0].Content != "Looking into it" || notes[0].User.ID != "PUSER1" {
+			t.Errorf("%v notes = %+v, want the note by PUSER1", id, notes)
+		}
+	}
+}
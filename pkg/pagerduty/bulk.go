@@ -0,0 +1,183 @@
+package pd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// MaxManageIncidents is the most incidents PagerDuty accepts in a single ManageIncidents request
+const MaxManageIncidents = 250
+
+// IncidentResult is the outcome of a bulk operation on one incident.
+type IncidentResult struct {
+	ID string `json:"id" yaml:"id"`
+	// Incident is the updated incident, set once PagerDuty applied the change
+	Incident *pagerduty.Incident `json:"incident,omitempty" yaml:"incident,omitempty"`
+	// Err is why the operation failed, or why a later step such as posting a note failed
+	Err error `json:"-" yaml:"-"`
+}
+
+// BulkResult is the outcome of a bulk operation on each of its incidents, in the order they were given.
+type BulkResult struct {
+	Results []IncidentResult
+}
+
+// Applied returns the incidents PagerDuty applied the change to, even if a later step failed.
+func (r *BulkResult) Applied() []pagerduty.Incident {
+	var incidents []pagerduty.Incident
+	for _, result := range r.Results {
+		if result.Incident != nil {
+			incidents = append(incidents, *result.Incident)
+		}
+	}
+	return incidents
+}
+
+// Failed returns the results of the incidents the operation failed on.
+func (r *BulkResult) Failed() []IncidentResult {
+	var failed []IncidentResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err returns an error listing the incidents the operation failed on, or nil if it succeeded on all of them.
+func (r *BulkResult) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	var msgs []string
+	for _, result := range failed {
+		msgs = append(msgs, fmt.Sprintf("%v: %v", result.ID, result.Err))
+	}
+	return fmt.Errorf("%v of %v incident(s) failed: %v", len(failed), len(r.Results), strings.Join(msgs, "; "))
+}
+
+// fail records err on the incident with the given ID
+func (r *BulkResult) fail(id string, err error) {
+	for i := range r.Results {
+		if r.Results[i].ID == id && r.Results[i].Err == nil {
+			r.Results[i].Err = err
+		}
+	}
+}
+
+// merge records the outcome of other for the incidents of r it covers
+func (r *BulkResult) merge(other *BulkResult) {
+	for _, o := range other.Results {
+		for i := range r.Results {
+			if r.Results[i].ID == o.ID {
+				r.Results[i].Incident = o.Incident
+				r.Results[i].Err = o.Err
+			}
+		}
+	}
+}
+
+// BulkManageIncidents calls BulkManageIncidentsWithContext with a background context.
+func BulkManageIncidents(client PagerDutyClient, from string, opts []pagerduty.ManageIncidentsOptions) *BulkResult {
+	return BulkManageIncidentsWithContext(context.Background(), client, from, opts)
+}
+
+// BulkManageIncidentsWithContext applies the changes to the incidents on behalf of the user with the
+// given email, in requests of at most MaxManageIncidents incidents. As PagerDuty rejects a whole request
+// when it refuses one of its incidents, a rejected request is split into one request per incident so
+// that the others still go through. It never stops early unless ctx is done, and returns the outcome
+// for every incident.
+func BulkManageIncidentsWithContext(ctx context.Context, client PagerDutyClient, from string, opts []pagerduty.ManageIncidentsOptions) *BulkResult {
+	result := &BulkResult{}
+	for _, o := range opts {
+		result.Results = append(result.Results, IncidentResult{ID: o.ID})
+	}
+
+	for start := 0; start < len(opts); start += MaxManageIncidents {
+		batch := opts[start:min(start+MaxManageIncidents, len(opts))]
+
+		err := manageBatch(ctx, client, from, batch, result)
+		if err == nil {
+			continue
+		}
+
+		if len(batch) == 1 || !rejected(err) {
+			for _, o := range batch {
+				result.fail(o.ID, err)
+			}
+			continue
+		}
+
+		for _, o := range batch {
+			if err := manageBatch(ctx, client, from, []pagerduty.ManageIncidentsOptions{o}, result); err != nil {
+				result.fail(o.ID, err)
+			}
+		}
+	}
+
+	return result
+}
+
+// manageBatch sends a single ManageIncidents request, recording the incidents PagerDuty returned as
+// updated and those it did not as failed
+func manageBatch(ctx context.Context, client PagerDutyClient, from string, batch []pagerduty.ManageIncidentsOptions, result *BulkResult) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	response, err := client.ManageIncidentsWithContext(ctx, from, batch)
+	if err != nil {
+		return err
+	}
+
+	// The response lists the updated incidents; More has no meaning here, so it is ignored
+	updated := map[string]pagerduty.Incident{}
+	for _, incident := range response.Incidents {
+		updated[incident.ID] = incident
+	}
+
+	for _, o := range batch {
+		incident, ok := updated[o.ID]
+		if !ok {
+			result.fail(o.ID, fmt.Errorf("not returned as updated by PagerDuty"))
+			continue
+		}
+		for i := range result.Results {
+			if result.Results[i].ID == o.ID {
+				result.Results[i].Incident = &incident
+			}
+		}
+	}
+
+	return nil
+}
+
+// rejected reports whether PagerDuty refused a request as a whole, without applying any of it
+func rejected(err error) bool {
+	var apiErr pagerduty.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError && !apiErr.RateLimited()
+}
+
+// incidentOptions builds the changes to apply to each incident, refusing nil incidents
+func incidentOptions(incidents []*pagerduty.Incident, change func(o *pagerduty.ManageIncidentsOptions)) ([]pagerduty.ManageIncidentsOptions, error) {
+	var opts []pagerduty.ManageIncidentsOptions
+	for _, incident := range incidents {
+		if incident == nil {
+			return nil, fmt.Errorf("incident is nil")
+		}
+		o := pagerduty.ManageIncidentsOptions{ID: incident.ID}
+		change(&o)
+		opts = append(opts, o)
+	}
+	return opts, nil
+}
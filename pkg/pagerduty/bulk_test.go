@@ -0,0 +1,123 @@
+package pd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+// countingClient counts the ManageIncidents requests and the incidents in each of them
+type countingClient struct {
+	*fake.Client
+
+	batches []int
+}
+
+func (c *countingClient) ManageIncidentsWithContext(ctx context.Context, email string, opts []pagerduty.ManageIncidentsOptions) (*pagerduty.ListIncidentsResponse, error) {
+	c.batches = append(c.batches, len(opts))
+	return c.Client.ManageIncidentsWithContext(ctx, email, opts)
+}
+
+// newBulkClient returns a client with n triggered incidents, PINC0001 onwards
+func newBulkClient(n int) *countingClient {
+	c := fake.New()
+	c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER1"}, Name: "Jane Doe", Email: "jane@example.com"})
+	for i := 1; i <= n; i++ {
+		c.AddIncident(pagerduty.Incident{APIObject: pagerduty.APIObject{ID: fmt.Sprintf("PINC%04d", i)}, Status: "triggered"})
+	}
+	return &countingClient{Client: c}
+}
+
+func acknowledge(ids ...string) []pagerduty.ManageIncidentsOptions {
+	var opts []pagerduty.ManageIncidentsOptions
+	for _, id := range ids {
+		opts = append(opts, pagerduty.ManageIncidentsOptions{ID: id, Status: "acknowledged"})
+	}
+	return opts
+}
+
+func acknowledged(t *testing.T, c *countingClient) int {
+	t.Helper()
+	incidents, err := GetIncidents(c, pagerduty.ListIncidentsOptions{Statuses: []string{"acknowledged"}})
+	if err != nil {
+		t.Fatalf("GetIncidents() error = %v", err)
+	}
+	return len(incidents)
+}
+
+func TestBulkManageIncidentsChunks(t *testing.T) {
+	c := newBulkClient(MaxManageIncidents + 50)
+
+	var ids []string
+	for i := 1; i <= MaxManageIncidents+50; i++ {
+		ids = append(ids, fmt.Sprintf("PINC%04d", i))
+	}
+
+	result := BulkManageIncidents(c, "jane@example.com", acknowledge(ids...))
+
+	if err := result.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if want := []int{MaxManageIncidents, 50}; fmt.Sprint(c.batches) != fmt.Sprint(want) {
+		t.Errorf("requests of %v incidents, want %v", c.batches, want)
+	}
+	if len(result.Applied()) != len(ids) || acknowledged(t, c) != len(ids) {
+		t.Errorf("applied %v, acknowledged %v, want %v", len(result.Applied()), acknowledged(t, c), len(ids))
+	}
+	for i, r := range result.Results {
+		if r.ID != ids[i] {
+			t.Fatalf("result %v is %v, want the results in the order given", i, r.ID)
+		}
+	}
+}
+
+func TestBulkManageIncidentsSplitsRejectedBatch(t *testing.T) {
+	c := newBulkClient(3)
+
+	result := BulkManageIncidents(c, "jane@example.com", acknowledge("PINC0001", "PNOPE", "PINC0002", "PINC0003"))
+
+	// The rejected request is retried one incident at a time
+	if want := []int{4, 1, 1, 1, 1}; fmt.Sprint(c.batches) != fmt.Sprint(want) {
+		t.Errorf("requests of %v incidents, want %v", c.batches, want)
+	}
+
+	failed := result.Failed()
+	if len(failed) != 1 || failed[0].ID != "PNOPE" || failed[0].Incident != nil {
+		t.Fatalf("Failed() = %+v, want PNOPE only", failed)
+	}
+	if err := result.Err(); err == nil || !strings.Contains(err.Error(), "1 of 4 incident(s) failed: PNOPE") {
+		t.Errorf("Err() = %v, want PNOPE reported", err)
+	}
+	if len(result.Applied()) != 3 || acknowledged(t, c) != 3 {
+		t.Errorf("applied %v, acknowledged %v, want the 3 valid incidents", len(result.Applied()), acknowledged(t, c))
+	}
+}
+
+func TestBulkManageIncidentsCancelled(t *testing.T) {
+	c := newBulkClient(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := BulkManageIncidentsWithContext(ctx, c, "jane@example.com", acknowledge("PINC0001", "PINC0002", "PINC0003"))
+
+	if len(c.batches) != 0 {
+		t.Errorf("requests of %v incidents, want none once cancelled", c.batches)
+	}
+	if len(result.Results) != 3 {
+		t.Fatalf("got %v results, want one per incident", len(result.Results))
+	}
+	for _, r := range result.Results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%v error = %v, want context.Canceled", r.ID, r.Err)
+		}
+	}
+	if acknowledged(t, c) != 0 {
+		t.Errorf("incidents acknowledged after cancellation")
+	}
+}
@@ -0,0 +1,302 @@
+package pd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// DefaultCacheTTL is how long services, users and teams are cached when no TTL is configured
+const DefaultCacheTTL = 5 * time.Minute
+
+// Kinds of objects kept by CachingClient
+const (
+	cacheServices = "services"
+	cacheUsers    = "users"
+	cacheTeams    = "teams"
+)
+
+// CacheStats counts the lookups of one kind of object served by a CachingClient.
+type CacheStats struct {
+	Hits        uint64 `json:"hits" yaml:"hits"`
+	Misses      uint64 `json:"misses" yaml:"misses"`
+	Expired     uint64 `json:"expired" yaml:"expired"`
+	Invalidated uint64 `json:"invalidated" yaml:"invalidated"`
+}
+
+// CachingClient wraps a PagerDutyClient to keep the services, users and teams it fetches for a TTL,
+// so that e.g. the cluster name lookup of 50 alerts of the same service only calls PagerDuty once.
+// Every other call goes straight to the wrapped client. Services are invalidated when
+// ManageIncidents or MergeIncidents changes the state of one of their incidents.
+type CachingClient struct {
+	PagerDutyClient
+
+	ttl  time.Duration
+	path string
+
+	// Now returns the current time, it can be replaced to control expiry
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	stats   map[string]*CacheStats
+	// dirty is set when entries changed since they were loaded or saved
+	dirty bool
+}
+
+type cacheEntry struct {
+	Value   json.RawMessage `json:"value"`
+	Expires time.Time       `json:"expires"`
+}
+
+// NewCachingClient wraps client with a cache keeping objects for ttl, or DefaultCacheTTL if ttl is 0.
+// If path is set the cache is loaded from that file and Save persists it there, so it is shared between
+// runs; entries that expired in the meantime are dropped when it is loaded.
+func NewCachingClient(client PagerDutyClient, ttl time.Duration, path string) (*CachingClient, error) {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	c := &CachingClient{
+		PagerDutyClient: client,
+		ttl:             ttl,
+		path:            path,
+		Now:             time.Now,
+		entries:         map[string]cacheEntry{},
+		stats: map[string]*CacheStats{
+			cacheServices: {},
+			cacheUsers:    {},
+			cacheTeams:    {},
+		},
+	}
+
+	if path == "" {
+		return c, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pd.NewCachingClient(): failed to read cache `%v`: %v", path, err)
+	}
+
+	// A corrupt cache is only a cache: start over rather than failing every command
+	if err := json.Unmarshal(b, &c.entries); err != nil {
+		c.entries = map[string]cacheEntry{}
+	}
+
+	now := c.Now()
+	for key, e := range c.entries {
+		if !now.Before(e.Expires) {
+			delete(c.entries, key)
+		}
+	}
+
+	return c, nil
+}
+
+func (c *CachingClient) GetServiceWithContext(ctx context.Context, serviceID string, opts *pagerduty.GetServiceOptions) (*pagerduty.Service, error) {
+	// Responses including related objects are not cached
+	if opts != nil && len(opts.Includes) > 0 {
+		return c.PagerDutyClient.GetServiceWithContext(ctx, serviceID, opts)
+	}
+
+	return cached(c, cacheServices, serviceID, func() (*pagerduty.Service, error) {
+		return c.PagerDutyClient.GetServiceWithContext(ctx, serviceID, opts)
+	})
+}
+
+func (c *CachingClient) GetUserWithContext(ctx context.Context, id string, opts pagerduty.GetUserOptions) (*pagerduty.User, error) {
+	if len(opts.Includes) > 0 {
+		return c.PagerDutyClient.GetUserWithContext(ctx, id, opts)
+	}
+
+	return cached(c, cacheUsers, id, func() (*pagerduty.User, error) {
+		return c.PagerDutyClient.GetUserWithContext(ctx, id, opts)
+	})
+}
+
+func (c *CachingClient) GetTeamWithContext(ctx context.Context, id string) (*pagerduty.Team, error) {
+	return cached(c, cacheTeams, id, func() (*pagerduty.Team, error) {
+		return c.PagerDutyClient.GetTeamWithContext(ctx, id)
+	})
+}
+
+// ManageIncidentsWithContext invalidates the services of the incidents it changes, as their status
+// depends on the state of their incidents.
+func (c *CachingClient) ManageIncidentsWithContext(ctx context.Context, email string, opts []pagerduty.ManageIncidentsOptions) (*pagerduty.ListIncidentsResponse, error) {
+	response, err := c.PagerDutyClient.ManageIncidentsWithContext(ctx, email, opts)
+	if err != nil {
+		return response, err
+	}
+
+	if response == nil || len(response.Incidents) == 0 {
+		c.invalidateKind(cacheServices)
+		return response, nil
+	}
+
+	for _, i := range response.Incidents {
+		c.invalidate(cacheServices, i.Service.ID)
+	}
+
+	return response, nil
+}
+
+// MergeIncidentsWithContext invalidates the services of the merged incidents, like
+// ManageIncidentsWithContext.
+func (c *CachingClient) MergeIncidentsWithContext(ctx context.Context, from, id string, sourceIncidents []pagerduty.MergeIncidentsOptions) (*pagerduty.Incident, error) {
+	incident, err := c.PagerDutyClient.MergeIncidentsWithContext(ctx, from, id, sourceIncidents)
+	if err != nil {
+		return incident, err
+	}
+
+	// The services of the source incidents are unknown here, so drop them all
+	c.invalidateKind(cacheServices)
+
+	return incident, nil
+}
+
+func (c *CachingClient) invalidateKind(kind string) {
+	c.mu.Lock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, kind+"/") {
+			delete(c.entries, key)
+			c.stats[kind].Invalidated++
+			c.dirty = true
+		}
+	}
+	c.mu.Unlock()
+}
+
+func (c *CachingClient) invalidate(kind, id string) {
+	c.mu.Lock()
+	key := cacheKey(kind, id)
+	if _, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		c.stats[kind].Invalidated++
+		c.dirty = true
+	}
+	c.mu.Unlock()
+}
+
+// Stats returns the cache statistics of each kind of object.
+func (c *CachingClient) Stats() map[string]CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := map[string]CacheStats{}
+	for kind, s := range c.stats {
+		stats[kind] = *s
+	}
+	return stats
+}
+
+// FormatCacheStats formats cache statistics as one line per kind of object.
+func FormatCacheStats(stats map[string]CacheStats) string {
+	var kinds []string
+	for kind := range stats {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var s string
+	for _, kind := range kinds {
+		st := stats[kind]
+		s += fmt.Sprintf("cache %-8v %v hits, %v misses, %v expired, %v invalidated\n", kind, st.Hits, st.Misses, st.Expired, st.Invalidated)
+	}
+	return s
+}
+
+func cacheKey(kind, id string) string {
+	return kind + "/" + id
+}
+
+// cached returns the object of the given kind and ID from the cache, or fetches and caches it.
+// Errors are not cached.
+func cached[T any](c *CachingClient, kind, id string, fetch func() (*T, error)) (*T, error) {
+	key := cacheKey(kind, id)
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	switch {
+	case ok && c.Now().Before(e.Expires):
+		var v T
+		if err := json.Unmarshal(e.Value, &v); err == nil {
+			c.stats[kind].Hits++
+			c.mu.Unlock()
+			return &v, nil
+		}
+		c.stats[kind].Misses++
+	case ok:
+		delete(c.entries, key)
+		c.dirty = true
+		c.stats[kind].Expired++
+		c.stats[kind].Misses++
+	default:
+		c.stats[kind].Misses++
+	}
+	c.mu.Unlock()
+
+	v, err := fetch()
+	if err != nil || v == nil {
+		return v, err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v, nil
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{Value: b, Expires: c.Now().Add(c.ttl)}
+	c.dirty = true
+	c.mu.Unlock()
+
+	return v, nil
+}
+
+// Save persists the cache to its file, if it has one and changed since it was loaded or last saved.
+// It is meant to be called once the cache is no longer needed, rather than on every change, so that
+// concurrent lookups never wait on the disk.
+func (c *CachingClient) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	b, err := json.Marshal(c.entries)
+	c.dirty = false
+	c.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("pd.CachingClient.Save(): failed to encode cache: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("pd.CachingClient.Save(): %v", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("pd.CachingClient.Save(): %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("pd.CachingClient.Save(): %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package pd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+// serviceCounter counts the service lookups reaching the emulated API
+type serviceCounter struct {
+	*fake.Client
+
+	calls int
+}
+
+func (c *serviceCounter) GetServiceWithContext(ctx context.Context, serviceID string, opts *pagerduty.GetServiceOptions) (*pagerduty.Service, error) {
+	c.calls++
+	return c.Client.GetServiceWithContext(ctx, serviceID, opts)
+}
+
+// newCacheBackend returns a client with two services, each with a triggered incident
+func newCacheBackend() *serviceCounter {
+	c := fake.New()
+	c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER1"}, Name: "Jane Doe", Email: "jane@example.com"})
+	for _, id := range []string{"PSVC1", "PSVC2"} {
+		c.AddService(pagerduty.Service{APIObject: pagerduty.APIObject{ID: id}, Name: id, Description: id + "-cluster"})
+	}
+	c.AddIncident(pagerduty.Incident{APIObject: pagerduty.APIObject{ID: "PINC1"}, Status: "triggered", Service: pagerduty.APIObject{ID: "PSVC1"}})
+	c.AddIncident(pagerduty.Incident{APIObject: pagerduty.APIObject{ID: "PINC2"}, Status: "triggered", Service: pagerduty.APIObject{ID: "PSVC2"}})
+	return &serviceCounter{Client: c}
+}
+
+// getServices looks up each service through the cache
+func getServices(t *testing.T, c *CachingClient, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		service, err := c.GetServiceWithContext(context.Background(), id, nil)
+		if err != nil || service.ID != id {
+			t.Fatalf("GetService(%v) = %+v, %v", id, service, err)
+		}
+	}
+}
+
+func TestCachingClientTTL(t *testing.T) {
+	backend := newCacheBackend()
+	c, err := NewCachingClient(backend, time.Minute, "")
+	if err != nil {
+		t.Fatalf("NewCachingClient() error = %v", err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c.Now = func() time.Time { return now }
+
+	getServices(t, c, "PSVC1", "PSVC1")
+	now = now.Add(59 * time.Second)
+	getServices(t, c, "PSVC1")
+	now = now.Add(time.Second)
+	getServices(t, c, "PSVC1", "PSVC1")
+
+	if backend.calls != 2 {
+		t.Errorf("got %v service lookup(s), want 2: the first one and the one after expiry", backend.calls)
+	}
+	want := CacheStats{Hits: 3, Misses: 2, Expired: 1}
+	if got := c.Stats()[cacheServices]; got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCachingClientErrorsNotCached(t *testing.T) {
+	backend := newCacheBackend()
+	c, err := NewCachingClient(backend, time.Minute, "")
+	if err != nil {
+		t.Fatalf("NewCachingClient() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetServiceWithContext(context.Background(), "PNOPE", nil); err == nil {
+			t.Fatalf("GetService() of an unknown service succeeded")
+		}
+	}
+	if backend.calls != 2 {
+		t.Errorf("got %v service lookup(s), want every failed lookup retried", backend.calls)
+	}
+}
+
+func TestCachingClientInvalidation(t *testing.T) {
+	tests := []struct {
+		name string
+		act  func(c *CachingClient) error
+		// wantCalls is the number of lookups reaching the API when both services are looked up again
+		wantCalls   int
+		invalidated uint64
+	}{
+		{
+			name: "manage invalidates the services of the incidents",
+			act: func(c *CachingClient) error {
+				_, err := c.ManageIncidentsWithContext(context.Background(), "jane@example.com", []pagerduty.ManageIncidentsOptions{{ID: "PINC1", Status: "acknowledged"}})
+				return err
+			},
+			wantCalls:   1,
+			invalidated: 1,
+		},
+		{
+			name: "merge invalidates every service",
+			act: func(c *CachingClient) error {
+				_, err := c.MergeIncidentsWithContext(context.Background(), "jane@example.com", "PINC1", []pagerduty.MergeIncidentsOptions{{ID: "PINC2", Type: "incident_reference"}})
+				return err
+			},
+			wantCalls:   2,
+			invalidated: 2,
+		},
+		{
+			name: "failed actions leave the cache as is",
+			act: func(c *CachingClient) error {
+				_, err := c.ManageIncidentsWithContext(context.Background(), "jane@example.com", []pagerduty.ManageIncidentsOptions{{ID: "PNOPE", Status: "acknowledged"}})
+				if err == nil {
+					t.Errorf("ManageIncidents() of an unknown incident succeeded")
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newCacheBackend()
+			c, err := NewCachingClient(backend, time.Minute, "")
+			if err != nil {
+				t.Fatalf("NewCachingClient() error = %v", err)
+			}
+
+			getServices(t, c, "PSVC1", "PSVC2")
+			backend.calls = 0
+
+			if err := tt.act(c); err != nil {
+				t.Fatalf("action error = %v", err)
+			}
+
+			getServices(t, c, "PSVC1", "PSVC2")
+			if backend.calls != tt.wantCalls {
+				t.Errorf("got %v service lookup(s), want %v", backend.calls, tt.wantCalls)
+			}
+			if got := c.Stats()[cacheServices].Invalidated; got != tt.invalidated {
+				t.Errorf("Invalidated = %v, want %v", got, tt.invalidated)
+			}
+		})
+	}
+}
+
+func TestCachingClientSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alertops", "cache.json")
+
+	c, err := NewCachingClient(newCacheBackend(), time.Hour, path)
+	if err != nil {
+		t.Fatalf("NewCachingClient() error = %v", err)
+	}
+
+	// Nothing is written until something is cached
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("cache file written with nothing cached: %v", err)
+	}
+
+	getServices(t, c, "PSVC1")
+	// Cached long ago, PSVC2 has expired by the time the cache is loaded again
+	c.Now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	getServices(t, c, "PSVC2")
+
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	backend := newCacheBackend()
+	loaded, err := NewCachingClient(backend, time.Hour, path)
+	if err != nil {
+		t.Fatalf("NewCachingClient() error = %v", err)
+	}
+
+	getServices(t, loaded, "PSVC1")
+	if backend.calls != 0 {
+		t.Errorf("PSVC1 looked up again after loading the cache")
+	}
+	getServices(t, loaded, "PSVC2")
+	if backend.calls != 1 {
+		t.Errorf("got %v lookup(s), want the expired PSVC2 dropped when the cache was loaded", backend.calls)
+	}
+}
+
+func TestCachingClientCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := newCacheBackend()
+	c, err := NewCachingClient(backend, time.Hour, path)
+	if err != nil {
+		t.Fatalf("NewCachingClient() error = %v, want a corrupt cache ignored", err)
+	}
+	getServices(t, c, "PSVC1")
+	if backend.calls != 1 {
+		t.Errorf("got %v lookup(s), want 1", backend.calls)
+	}
+}
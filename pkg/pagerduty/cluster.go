@@ -0,0 +1,158 @@
+package pd
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// UnknownCluster is the ID and name of the group of alerts no cluster could be found for
+const UnknownCluster = "N/A"
+
+// ClusterGroup gathers the open incidents with at least one alert on the same cluster. An incident
+// whose alerts fire on several clusters belongs to the group of each of them.
+type ClusterGroup struct {
+	ClusterID      string   `json:"cluster_id" yaml:"cluster_id"`
+	ClusterName    string   `json:"cluster_name" yaml:"cluster_name"`
+	IncidentIDs    []string `json:"incident_ids" yaml:"incident_ids"`
+	Alerts         int      `json:"alerts" yaml:"alerts"`
+	HighestUrgency string   `json:"highest_urgency" yaml:"highest_urgency"`
+	Severity       string   `json:"severity" yaml:"severity"`
+	OldestTrigger  string   `json:"oldest_trigger" yaml:"oldest_trigger"`
+	Sops           []string `json:"sops" yaml:"sops"`
+
+	// Incidents are the incidents of the group, for bulk actions
+	Incidents []pagerduty.Incident `json:"-" yaml:"-"`
+}
+
+// Matches reports whether s is the ID or the name of the cluster.
+func (g ClusterGroup) Matches(s string) bool {
+	return s != "" && (s == g.ClusterID || strings.EqualFold(s, g.ClusterName))
+}
+
+// GroupByCluster groups the enriched incidents by the clusters their alerts fire on. Groups are
+// sorted by highest urgency, then severity, then oldest trigger, with alerts of unknown clusters last.
+func GroupByCluster(incidents []EnrichedIncident) []ClusterGroup {
+	groups := map[string]*ClusterGroup{}
+	var order []string
+
+	for _, e := range incidents {
+		alerts := e.Alerts
+		// An incident without alerts still needs to show up somewhere
+		if len(alerts) == 0 {
+			alerts = []Alert{{}}
+		}
+
+		for _, a := range alerts {
+			key, id, name := clusterKey(a)
+
+			g, ok := groups[key]
+			if !ok {
+				g = &ClusterGroup{ClusterID: id, ClusterName: name, IncidentIDs: []string{}, Sops: []string{}}
+				groups[key] = g
+				order = append(order, key)
+			}
+
+			if a.AlertID != "" {
+				g.Alerts++
+			}
+			if SeverityRank(a.Severity) < SeverityRank(g.Severity) {
+				g.Severity = a.Severity
+			}
+			if a.Sop != "" && !slices.Contains(g.Sops, a.Sop) {
+				g.Sops = append(g.Sops, a.Sop)
+			}
+			if g.ClusterName == UnknownCluster && name != UnknownCluster {
+				g.ClusterName = name
+			}
+
+			if slices.Contains(g.IncidentIDs, e.Incident.ID) {
+				continue
+			}
+			g.IncidentIDs = append(g.IncidentIDs, e.Incident.ID)
+			g.Incidents = append(g.Incidents, e.Incident)
+
+			if urgencyRank(e.Incident.Urgency) < urgencyRank(g.HighestUrgency) {
+				g.HighestUrgency = e.Incident.Urgency
+			}
+			if g.OldestTrigger == "" || before(e.Incident.CreatedAt, g.OldestTrigger) {
+				g.OldestTrigger = e.Incident.CreatedAt
+			}
+		}
+	}
+
+	var sorted []ClusterGroup
+	for _, key := range order {
+		sorted = append(sorted, *groups[key])
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if unknown := a.ClusterID == UnknownCluster; unknown != (b.ClusterID == UnknownCluster) {
+			return !unknown
+		}
+		if urgencyRank(a.HighestUrgency) != urgencyRank(b.HighestUrgency) {
+			return urgencyRank(a.HighestUrgency) < urgencyRank(b.HighestUrgency)
+		}
+		if SeverityRank(a.Severity) != SeverityRank(b.Severity) {
+			return SeverityRank(a.Severity) < SeverityRank(b.Severity)
+		}
+		return before(a.OldestTrigger, b.OldestTrigger)
+	})
+
+	return sorted
+}
+
+// FindClusterGroup returns the group whose cluster ID or name is s.
+func FindClusterGroup(groups []ClusterGroup, s string) (ClusterGroup, bool) {
+	for _, g := range groups {
+		if g.Matches(s) {
+			return g, true
+		}
+	}
+	return ClusterGroup{}, false
+}
+
+// clusterKey identifies the cluster of an alert by its ID, falling back on its name
+func clusterKey(a Alert) (key, id, name string) {
+	id, name = a.ClusterID, a.ClusterName
+	if id == "" {
+		id = UnknownCluster
+	}
+	if name == "" {
+		name = UnknownCluster
+	}
+
+	switch {
+	case id != UnknownCluster:
+		return "id:" + id, id, name
+	case name != UnknownCluster:
+		return "name:" + name, id, name
+	default:
+		return UnknownCluster, UnknownCluster, UnknownCluster
+	}
+}
+
+func urgencyRank(urgency string) int {
+	switch urgency {
+	case "high":
+		return 0
+	case "low":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// before compares two PagerDuty timestamps, falling back on comparing them as strings
+func before(a, b string) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return ta.Before(tb)
+}
@@ -0,0 +1,78 @@
+package pd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+func enrichedIncident(id, urgency, createdAt string, alerts ...Alert) EnrichedIncident {
+	return EnrichedIncident{
+		Incident: pagerduty.Incident{APIObject: pagerduty.APIObject{ID: id}, Urgency: urgency, CreatedAt: createdAt},
+		Alerts:   alerts,
+	}
+}
+
+// summary describes a group without its incidents
+func summary(g ClusterGroup) string {
+	return fmt.Sprintf("%v/%v %v alerts=%v urgency=%v severity=%v oldest=%v sops=%v",
+		g.ClusterID, g.ClusterName, g.IncidentIDs, g.Alerts, g.HighestUrgency, g.Severity, g.OldestTrigger, g.Sops)
+}
+
+func TestGroupByCluster(t *testing.T) {
+	groups := GroupByCluster([]EnrichedIncident{
+		enrichedIncident("PINC1", "low", "2024-05-01T10:00:00Z",
+			Alert{AlertID: "PA1", ClusterID: "c-1", ClusterName: "east", Severity: SeverityWarning, Sop: "https://sop/a"},
+			// On a second cluster as well
+			Alert{AlertID: "PA2", ClusterID: "c-2", Severity: SeverityInfo},
+		),
+		enrichedIncident("PINC2", "high", "2024-05-01T11:00:00Z",
+			Alert{AlertID: "PA3", ClusterID: "c-1", Severity: SeverityCritical, Sop: "https://sop/a"},
+			Alert{AlertID: "PA4", ClusterID: "c-1", Severity: SeverityError, Sop: "https://sop/b"},
+		),
+		enrichedIncident("PINC3", "high", "2024-05-01T09:00:00+02:00",
+			Alert{AlertID: "PA5", ClusterID: "c-2", ClusterName: "west", Severity: SeverityWarning},
+		),
+		// Known by name only
+		enrichedIncident("PINC4", "low", "2024-05-01T08:00:00Z",
+			Alert{AlertID: "PA6", ClusterName: "north", Severity: SeverityCritical},
+		),
+		enrichedIncident("PINC5", "high", "2024-05-01T07:00:00Z",
+			Alert{AlertID: "PA7", Severity: SeverityCritical},
+		),
+		// Without alerts
+		enrichedIncident("PINC6", "high", "2024-05-01T06:00:00Z"),
+	})
+
+	want := []ClusterGroup{
+		// Both high urgency, critical before warning
+		{ClusterID: "c-1", ClusterName: "east", IncidentIDs: []string{"PINC1", "PINC2"}, Alerts: 3, HighestUrgency: "high", Severity: SeverityCritical, OldestTrigger: "2024-05-01T10:00:00Z", Sops: []string{"https://sop/a", "https://sop/b"}},
+		// The name of a later alert fills in the unknown name, and the oldest trigger is compared in UTC
+		{ClusterID: "c-2", ClusterName: "west", IncidentIDs: []string{"PINC1", "PINC3"}, Alerts: 2, HighestUrgency: "high", Severity: SeverityWarning, OldestTrigger: "2024-05-01T09:00:00+02:00", Sops: []string{}},
+		// Clusters without an ID last however urgent, then by urgency among themselves
+		{ClusterID: UnknownCluster, ClusterName: UnknownCluster, IncidentIDs: []string{"PINC5", "PINC6"}, Alerts: 1, HighestUrgency: "high", Severity: SeverityCritical, OldestTrigger: "2024-05-01T06:00:00Z", Sops: []string{}},
+		{ClusterID: UnknownCluster, ClusterName: "north", IncidentIDs: []string{"PINC4"}, Alerts: 1, HighestUrgency: "low", Severity: SeverityCritical, OldestTrigger: "2024-05-01T08:00:00Z", Sops: []string{}},
+	}
+
+	if len(groups) != len(want) {
+		t.Fatalf("GroupByCluster() returned %v groups, want %v", len(groups), len(want))
+	}
+	for i, g := range groups {
+		if got, want := summary(g), summary(want[i]); got != want {
+			t.Errorf("group %v = %v, want %v", i, got, want)
+		}
+		if len(g.Incidents) != len(g.IncidentIDs) {
+			t.Errorf("group %v has %v incidents for %v IDs", i, len(g.Incidents), len(g.IncidentIDs))
+		}
+	}
+
+	for _, s := range []string{"c-2", "WEST"} {
+		if g, ok := FindClusterGroup(groups, s); !ok || g.ClusterID != "c-2" {
+			t.Errorf("FindClusterGroup(%v) = %v, %v, want c-2", s, g.ClusterID, ok)
+		}
+	}
+	if _, ok := FindClusterGroup(groups, ""); ok {
+		t.Errorf("FindClusterGroup() of nothing found a group")
+	}
+}
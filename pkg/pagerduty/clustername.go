@@ -0,0 +1,318 @@
+package pd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	config "github.com/aliceh/alertops/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Types of cluster name resolvers
+const (
+	ResolverServiceDescription = "service_description"
+	ResolverServiceName        = "service_name"
+	ResolverAlertDetail        = "alert_detail"
+	ResolverMappingFile        = "mapping_file"
+	ResolverCommand            = "command"
+)
+
+const defaultResolverCommandTimeout = 10 * time.Second
+
+// ClusterNameInput is what a resolver knows about the alert whose cluster it names.
+type ClusterNameInput struct {
+	Alert     *pagerduty.IncidentAlert
+	Details   map[string]interface{}
+	ClusterID string
+
+	// service returns the alert service, fetched at most once per alert
+	service func() (*pagerduty.Service, error)
+}
+
+// ClusterNameResolver names the cluster of an alert. It returns "" if it cannot tell.
+type ClusterNameResolver interface {
+	ResolveClusterName(ctx context.Context, in ClusterNameInput) (string, error)
+}
+
+type resolverChain struct {
+	teams     []string
+	resolvers []ClusterNameResolver
+}
+
+var (
+	resolversMu sync.RWMutex
+	chains      []resolverChain
+)
+
+// SetClusterNameResolvers replaces the cluster name resolver chains with the configured ones.
+func SetClusterNameResolvers(cfg []config.ClusterNameChain) error {
+	var built []resolverChain
+
+	for i, chain := range cfg {
+		var resolvers []ClusterNameResolver
+		for j, r := range chain.Resolvers {
+			resolver, err := NewClusterNameResolver(r)
+			if err != nil {
+				return fmt.Errorf("pd.SetClusterNameResolvers(): resolver %v of chain %v: %v", j+1, i+1, err)
+			}
+			resolvers = append(resolvers, resolver)
+		}
+		built = append(built, resolverChain{teams: chain.Teams, resolvers: resolvers})
+	}
+
+	resolversMu.Lock()
+	chains = built
+	resolversMu.Unlock()
+
+	return nil
+}
+
+// NewClusterNameResolver builds a resolver from its configuration.
+func NewClusterNameResolver(cfg config.ClusterNameResolver) (ClusterNameResolver, error) {
+	var re *regexp.Regexp
+	if cfg.Regex != "" {
+		var err error
+		re, err = regexp.Compile(cfg.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %v", err)
+		}
+	}
+
+	switch cfg.Type {
+	case ResolverServiceDescription, ResolverServiceName:
+		if re == nil {
+			return nil, fmt.Errorf("%v resolver needs a regex", cfg.Type)
+		}
+		return &serviceResolver{description: cfg.Type == ResolverServiceDescription, regex: re}, nil
+
+	case ResolverAlertDetail:
+		path, err := parsePath(cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key: %v", err)
+		}
+		return &detailResolver{path: path, regex: re}, nil
+
+	case ResolverMappingFile:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("mapping_file resolver needs a path")
+		}
+		return &mappingResolver{path: os.ExpandEnv(cfg.Path)}, nil
+
+	case ResolverCommand:
+		if len(cfg.Command) == 0 {
+			return nil, fmt.Errorf("command resolver needs a command")
+		}
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = defaultResolverCommandTimeout
+		}
+		return &commandResolver{command: cfg.Command, timeout: timeout, names: map[string]string{}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown resolver type `%v`", cfg.Type)
+}
+
+// resolveClusterName runs the resolver chain of the alert's team, returning "" if there is none or
+// none of its resolvers could name the cluster
+func resolveClusterName(ctx context.Context, c PagerDutyClient, alert *pagerduty.IncidentAlert, details map[string]interface{}, clusterID string) string {
+	resolversMu.RLock()
+	configured := chains
+	resolversMu.RUnlock()
+
+	if len(configured) == 0 {
+		return ""
+	}
+
+	var once sync.Once
+	var service *pagerduty.Service
+	var serviceErr error
+	in := ClusterNameInput{
+		Alert:     alert,
+		Details:   details,
+		ClusterID: clusterID,
+		service: func() (*pagerduty.Service, error) {
+			once.Do(func() {
+				service, serviceErr = c.GetServiceWithContext(ctx, alert.Service.ID, &pagerduty.GetServiceOptions{})
+			})
+			return service, serviceErr
+		},
+	}
+
+	chain := chainFor(configured, in)
+	if chain == nil {
+		return ""
+	}
+
+	for _, r := range chain.resolvers {
+		// A resolver failing only means the next one gets a chance
+		if name, err := r.ResolveClusterName(ctx, in); err == nil && name != "" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// chainFor returns the chain of the team owning the alert service, or the chain without teams
+func chainFor(configured []resolverChain, in ClusterNameInput) *resolverChain {
+	var fallback *resolverChain
+	needTeams := false
+	for i := range configured {
+		if len(configured[i].teams) == 0 {
+			if fallback == nil {
+				fallback = &configured[i]
+			}
+		} else {
+			needTeams = true
+		}
+	}
+
+	if !needTeams {
+		return fallback
+	}
+
+	service, err := in.service()
+	if err != nil {
+		return fallback
+	}
+
+	for i := range configured {
+		for _, team := range service.Teams {
+			for _, id := range configured[i].teams {
+				if team.ID == id {
+					return &configured[i]
+				}
+			}
+		}
+	}
+
+	return fallback
+}
+
+// applyRegex returns the first capture group of the regex, the whole match if it has none, or ""
+func applyRegex(re *regexp.Regexp, s string) string {
+	if re == nil {
+		return strings.TrimSpace(s)
+	}
+	match := re.FindStringSubmatch(s)
+	switch {
+	case match == nil:
+		return ""
+	case len(match) > 1:
+		return match[1]
+	default:
+		return match[0]
+	}
+}
+
+type serviceResolver struct {
+	description bool
+	regex       *regexp.Regexp
+}
+
+func (r *serviceResolver) ResolveClusterName(ctx context.Context, in ClusterNameInput) (string, error) {
+	service, err := in.service()
+	if err != nil {
+		return "", err
+	}
+	if r.description {
+		return applyRegex(r.regex, service.Description), nil
+	}
+	return applyRegex(r.regex, service.Name), nil
+}
+
+type detailResolver struct {
+	path  []pathStep
+	regex *regexp.Regexp
+}
+
+func (r *detailResolver) ResolveClusterName(ctx context.Context, in ClusterNameInput) (string, error) {
+	v, ok := lookupPath(in.Details, r.path)
+	if !ok {
+		return "", nil
+	}
+	return applyRegex(r.regex, fmt.Sprint(v)), nil
+}
+
+// mappingResolver loads its file on first use
+type mappingResolver struct {
+	path string
+
+	once  sync.Once
+	names map[string]string
+	err   error
+}
+
+func (r *mappingResolver) ResolveClusterName(ctx context.Context, in ClusterNameInput) (string, error) {
+	r.once.Do(func() {
+		b, err := os.ReadFile(r.path)
+		if err != nil {
+			r.err = err
+			return
+		}
+		// YAML is a superset of JSON, so this reads both
+		r.err = yaml.Unmarshal(b, &r.names)
+	})
+	if r.err != nil {
+		return "", fmt.Errorf("failed to load cluster names from `%v`: %v", r.path, r.err)
+	}
+	return r.names[in.ClusterID], nil
+}
+
+// commandResolver remembers the name of each cluster so the command runs once per cluster
+type commandResolver struct {
+	command []string
+	timeout time.Duration
+
+	mu    sync.Mutex
+	names map[string]string
+}
+
+func (r *commandResolver) ResolveClusterName(ctx context.Context, in ClusterNameInput) (string, error) {
+	if in.ClusterID == "" || in.ClusterID == "N/A" {
+		return "", nil
+	}
+
+	r.mu.Lock()
+	name, ok := r.names[in.ClusterID]
+	r.mu.Unlock()
+	if ok {
+		return name, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.command[0], r.command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"ALERTOPS_CLUSTER_ID="+in.ClusterID,
+		"ALERTOPS_ALERT_ID="+in.Alert.ID,
+		"ALERTOPS_SERVICE_ID="+in.Alert.Service.ID,
+	)
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("`%v` failed: %v", strings.Join(r.command, " "), err)
+	}
+
+	scanner := bufio.NewScanner(&stdout)
+	if scanner.Scan() {
+		name = strings.TrimSpace(scanner.Text())
+	}
+
+	r.mu.Lock()
+	r.names[in.ClusterID] = name
+	r.mu.Unlock()
+
+	return name, nil
+}
@@ -0,0 +1,164 @@
+package pd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PagerDuty/go-pagerduty"
+	config "github.com/aliceh/alertops/pkg/config"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+// withResolvers configures the resolver chains for the duration of the test
+func withResolvers(t *testing.T, cfg []config.ClusterNameChain) {
+	t.Helper()
+
+	if err := SetClusterNameResolvers(cfg); err != nil {
+		t.Fatalf("SetClusterNameResolvers() error = %v", err)
+	}
+	t.Cleanup(func() { SetClusterNameResolvers(nil) })
+}
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolveClusterName(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run the command resolver with")
+	}
+
+	c := fake.New()
+	for _, s := range []pagerduty.Service{
+		{APIObject: pagerduty.APIObject{ID: "PSVCA1"}, Name: "prod-east-1-hive-cluster", Description: "prod-east-1 OpenShift cluster", Teams: []pagerduty.Team{{APIObject: pagerduty.APIObject{ID: "PTEAMA"}}}},
+		{APIObject: pagerduty.APIObject{ID: "PSVCA2"}, Name: "osd-alerts", Description: "unnamed", Teams: []pagerduty.Team{{APIObject: pagerduty.APIObject{ID: "PTEAMA"}}}},
+		{APIObject: pagerduty.APIObject{ID: "PSVCB1"}, Name: "payments-api", Description: "payments", Teams: []pagerduty.Team{{APIObject: pagerduty.APIObject{ID: "PTEAMB"}}}},
+		{APIObject: pagerduty.APIObject{ID: "PSVCB2"}, Name: "batch", Teams: []pagerduty.Team{{APIObject: pagerduty.APIObject{ID: "PTEAMC"}}, {APIObject: pagerduty.APIObject{ID: "PTEAMB"}}}},
+		{APIObject: pagerduty.APIObject{ID: "PSVC3"}, Name: "prod-west-2-hive-cluster"},
+	} {
+		c.AddService(s)
+	}
+
+	mapping := writeTestFile(t, "clusters.yaml", "c-111: mapped-one\nc-222: mapped-two\n")
+	runs := filepath.Join(t.TempDir(), "runs")
+
+	withResolvers(t, []config.ClusterNameChain{
+		{
+			Teams: []string{"PTEAMA"},
+			Resolvers: []config.ClusterNameResolver{
+				{Type: ResolverAlertDetail, Key: "$.cluster_name", Regex: `^([^.]+)\.`},
+				{Type: ResolverServiceDescription, Regex: `^(\S+) `},
+				{Type: ResolverMappingFile, Path: mapping},
+			},
+		},
+		{
+			Teams: []string{"PTEAMB"},
+			Resolvers: []config.ClusterNameResolver{
+				{Type: ResolverServiceName, Regex: `^(.+)-api$`},
+				{Type: ResolverCommand, Command: []string{"sh", "-c", `echo run >> "$RUNS"; echo "cmd-$ALERTOPS_CLUSTER_ID"; echo ignored`}},
+			},
+		},
+		{
+			// Chains without teams apply to the services of every other team
+			Resolvers: []config.ClusterNameResolver{
+				{Type: ResolverMappingFile, Path: filepath.Join(t.TempDir(), "missing.yaml")},
+				{Type: ResolverMappingFile, Path: mapping},
+				{Type: ResolverServiceName, Regex: `^(.+)-hive-cluster$`},
+			},
+		},
+	})
+	t.Setenv("RUNS", runs)
+
+	tests := []struct {
+		name      string
+		service   string
+		details   map[string]interface{}
+		clusterID string
+		want      string
+	}{
+		{name: "detail regex capture", service: "PSVCA1", details: map[string]interface{}{"cluster_name": "detail-one.example.com"}, want: "detail-one"},
+		{name: "detail not matching the regex falls back to the description", service: "PSVCA1", details: map[string]interface{}{"cluster_name": "no-domain"}, want: "prod-east-1"},
+		{name: "description regex capture", service: "PSVCA1", want: "prod-east-1"},
+		{name: "mapping file after an empty description match", service: "PSVCA2", clusterID: "c-111", want: "mapped-one"},
+		{name: "no resolver of the team can tell", service: "PSVCA2", clusterID: "c-999", want: ""},
+		{name: "service name regex capture", service: "PSVCB1", clusterID: "c-222", want: "payments"},
+		{name: "command when the service name does not match", service: "PSVCB2", clusterID: "c-222", want: "cmd-c-222"},
+		{name: "command needs a cluster ID", service: "PSVCB2", clusterID: UnknownCluster, want: ""},
+		{name: "chain without teams for services without a configured team", service: "PSVC3", clusterID: "c-222", want: "mapped-two"},
+		{name: "chain without teams ignores the detail resolvers of other teams", service: "PSVC3", details: map[string]interface{}{"cluster_name": "detail-one.example.com"}, want: "prod-west-2"},
+		{name: "chain without teams for unknown services", service: "PNOPE", clusterID: "c-111", want: "mapped-one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := &pagerduty.IncidentAlert{APIObject: pagerduty.APIObject{ID: "PALERT1"}, Service: pagerduty.APIObject{ID: tt.service}}
+			if got := resolveClusterName(context.Background(), c, alert, tt.details, tt.clusterID); got != tt.want {
+				t.Errorf("resolveClusterName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("command runs once per cluster", func(t *testing.T) {
+		os.Remove(runs)
+
+		for _, id := range []string{"c-333", "c-333", "c-444", "c-333"} {
+			alert := &pagerduty.IncidentAlert{APIObject: pagerduty.APIObject{ID: "PALERT1"}, Service: pagerduty.APIObject{ID: "PSVCB2"}}
+			if got := resolveClusterName(context.Background(), c, alert, nil, id); got != "cmd-"+id {
+				t.Errorf("resolveClusterName(%v) = %q, want %q", id, got, "cmd-"+id)
+			}
+		}
+
+		b, err := os.ReadFile(runs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(string(b), "run"); n != 2 {
+			t.Errorf("command ran %v time(s), want once for each of the 2 clusters", n)
+		}
+	})
+}
+
+func TestResolveClusterNameWithoutChains(t *testing.T) {
+	withResolvers(t, nil)
+
+	alert := &pagerduty.IncidentAlert{Service: pagerduty.APIObject{ID: "PSVC1"}}
+	if got := resolveClusterName(context.Background(), fake.New(), alert, nil, "c-111"); got != "" {
+		t.Errorf("resolveClusterName() = %q, want the parser's guess kept", got)
+	}
+}
+
+func TestNewClusterNameResolver(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ClusterNameResolver
+		wantErr string
+	}{
+		{name: "service description", cfg: config.ClusterNameResolver{Type: ResolverServiceDescription, Regex: `^(\S+)`}},
+		{name: "service name without regex", cfg: config.ClusterNameResolver{Type: ResolverServiceName}, wantErr: "needs a regex"},
+		{name: "invalid regex", cfg: config.ClusterNameResolver{Type: ResolverServiceName, Regex: "("}, wantErr: "invalid regex"},
+		{name: "alert detail", cfg: config.ClusterNameResolver{Type: ResolverAlertDetail, Key: "name"}},
+		{name: "alert detail with an invalid key", cfg: config.ClusterNameResolver{Type: ResolverAlertDetail, Key: "$['']"}, wantErr: "invalid key"},
+		{name: "mapping file without path", cfg: config.ClusterNameResolver{Type: ResolverMappingFile}, wantErr: "needs a path"},
+		{name: "command without command", cfg: config.ClusterNameResolver{Type: ResolverCommand}, wantErr: "needs a command"},
+		{name: "unknown type", cfg: config.ClusterNameResolver{Type: "dns"}, wantErr: "unknown resolver type `dns`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClusterNameResolver(tt.cfg)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("NewClusterNameResolver() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
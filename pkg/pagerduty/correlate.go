@@ -0,0 +1,220 @@
+package pd
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// Defaults of CorrelationOptions
+const (
+	DefaultCorrelationWindow     = 30 * time.Minute
+	DefaultCorrelationSimilarity = 0.5
+)
+
+// CorrelationOptions tunes how incidents are correlated into problems.
+type CorrelationOptions struct {
+	// Window is how far apart two incidents can be triggered and still be correlated
+	Window time.Duration
+	// Similarity is the share of labels two alerts with different names must have in common to be
+	// correlated, from 0 to 1
+	Similarity float64
+}
+
+// Problem is a set of incidents believed to share a root cause: each of them has an alert on the
+// same cluster as another member, triggered within the correlation window, with the same alert
+// name or similar labels. The oldest incident is the primary one, the others can be merged into it.
+type Problem struct {
+	// ID is the ID of the primary incident
+	ID             string   `json:"id" yaml:"id"`
+	ClusterID      string   `json:"cluster_id" yaml:"cluster_id"`
+	ClusterName    string   `json:"cluster_name" yaml:"cluster_name"`
+	AlertNames     []string `json:"alert_names" yaml:"alert_names"`
+	IncidentIDs    []string `json:"incident_ids" yaml:"incident_ids"`
+	Alerts         int      `json:"alerts" yaml:"alerts"`
+	HighestUrgency string   `json:"highest_urgency" yaml:"highest_urgency"`
+	Severity       string   `json:"severity" yaml:"severity"`
+	FirstTrigger   string   `json:"first_trigger" yaml:"first_trigger"`
+	LastTrigger    string   `json:"last_trigger" yaml:"last_trigger"`
+
+	// Primary is the incident the others are merged into, Duplicates the others
+	Primary    pagerduty.Incident   `json:"-" yaml:"-"`
+	Duplicates []pagerduty.Incident `json:"-" yaml:"-"`
+}
+
+// Correlate groups the enriched incidents into problems, largest first. Every incident belongs to
+// exactly one problem; incidents nothing correlates with are problems of their own.
+func Correlate(incidents []EnrichedIncident, opts CorrelationOptions) []Problem {
+	if opts.Window <= 0 {
+		opts.Window = DefaultCorrelationWindow
+	}
+	if opts.Similarity <= 0 {
+		opts.Similarity = DefaultCorrelationSimilarity
+	}
+
+	// Union-find over the incidents, joining every correlated pair
+	parent := make([]int, len(incidents))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range incidents {
+		for j := i + 1; j < len(incidents); j++ {
+			if correlated(incidents[i], incidents[j], opts) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := map[int][]EnrichedIncident{}
+	var roots []int
+	for i := range incidents {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], incidents[i])
+	}
+
+	var problems []Problem
+	for _, root := range roots {
+		problems = append(problems, newProblem(members[root]))
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if len(problems[i].IncidentIDs) != len(problems[j].IncidentIDs) {
+			return len(problems[i].IncidentIDs) > len(problems[j].IncidentIDs)
+		}
+		return SeverityRank(problems[i].Severity) < SeverityRank(problems[j].Severity)
+	})
+
+	return problems
+}
+
+// FindProblem returns the problem with the given ID, or the one the incident with that ID belongs to.
+func FindProblem(problems []Problem, id string) (Problem, bool) {
+	for _, p := range problems {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	for _, p := range problems {
+		if slices.Contains(p.IncidentIDs, id) {
+			return p, true
+		}
+	}
+	return Problem{}, false
+}
+
+// correlated reports whether two incidents have alerts on the same known cluster, triggered within
+// the window, with the same name or similar labels
+func correlated(a, b EnrichedIncident, opts CorrelationOptions) bool {
+	if !within(a.Incident.CreatedAt, b.Incident.CreatedAt, opts.Window) {
+		return false
+	}
+
+	for _, x := range a.Alerts {
+		for _, y := range b.Alerts {
+			if x.ClusterID == "" || x.ClusterID == UnknownCluster || x.ClusterID != y.ClusterID {
+				continue
+			}
+			if alertName(x) == alertName(y) || labelSimilarity(x.AlertLabels, y.AlertLabels) >= opts.Similarity {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func within(a, b string, window time.Duration) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	d := ta.Sub(tb)
+	if d < 0 {
+		d = -d
+	}
+	return d <= window
+}
+
+// alertName is the Alertmanager alert name if the alert has one, or its summary
+func alertName(a Alert) string {
+	if name := a.AlertLabels["alertname"]; name != "" {
+		return name
+	}
+	return a.Name
+}
+
+// labelSimilarity is the Jaccard index of two label sets, 0 if both are empty
+func labelSimilarity(a, b map[string]string) float64 {
+	union := len(a)
+	shared := 0
+	for k, v := range b {
+		if w, ok := a[k]; ok && w == v {
+			shared++
+		} else {
+			union++
+		}
+	}
+
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+func newProblem(members []EnrichedIncident) Problem {
+	sort.SliceStable(members, func(i, j int) bool {
+		return before(members[i].Incident.CreatedAt, members[j].Incident.CreatedAt)
+	})
+
+	p := Problem{
+		ID:           members[0].Incident.ID,
+		ClusterID:    UnknownCluster,
+		ClusterName:  UnknownCluster,
+		AlertNames:   []string{},
+		IncidentIDs:  []string{},
+		FirstTrigger: members[0].Incident.CreatedAt,
+		LastTrigger:  members[len(members)-1].Incident.CreatedAt,
+		Primary:      members[0].Incident,
+	}
+
+	for i, e := range members {
+		p.IncidentIDs = append(p.IncidentIDs, e.Incident.ID)
+		if i > 0 {
+			p.Duplicates = append(p.Duplicates, e.Incident)
+		}
+
+		if urgencyRank(e.Incident.Urgency) < urgencyRank(p.HighestUrgency) {
+			p.HighestUrgency = e.Incident.Urgency
+		}
+
+		for _, a := range e.Alerts {
+			p.Alerts++
+			if SeverityRank(a.Severity) < SeverityRank(p.Severity) {
+				p.Severity = a.Severity
+			}
+			if name := alertName(a); name != "" && !slices.Contains(p.AlertNames, name) {
+				p.AlertNames = append(p.AlertNames, name)
+			}
+			if p.ClusterID == UnknownCluster && a.ClusterID != "" && a.ClusterID != UnknownCluster {
+				p.ClusterID, p.ClusterName = a.ClusterID, a.ClusterName
+			}
+		}
+	}
+
+	return p
+}
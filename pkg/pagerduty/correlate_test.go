@@ -0,0 +1,135 @@
+package pd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+var correlateStart = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newCorrelated returns an incident triggered after the offset, with one alert on the cluster
+func newCorrelated(id string, offset time.Duration, clusterID string, labels map[string]string) EnrichedIncident {
+	return EnrichedIncident{
+		Incident: pagerduty.Incident{
+			APIObject: pagerduty.APIObject{ID: id},
+			CreatedAt: correlateStart.Add(offset).Format(time.RFC3339),
+		},
+		Alerts: []Alert{{ClusterID: clusterID, AlertLabels: labels}},
+	}
+}
+
+func alertLabels(name string, pairs ...string) map[string]string {
+	labels := map[string]string{"alertname": name}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels[pairs[i]] = pairs[i+1]
+	}
+	return labels
+}
+
+func TestCorrelate(t *testing.T) {
+	down := alertLabels("ClusterOperatorDown")
+
+	// Share namespace and job out of 4 distinct labels: a Jaccard index of exactly 0.5
+	apiserver := alertLabels("KubeAPIDown", "namespace", "openshift-kube-apiserver", "job", "apiserver")
+	budgetBurn := alertLabels("KubeAPIErrorBudgetBurn", "namespace", "openshift-kube-apiserver", "job", "apiserver")
+
+	tests := []struct {
+		name       string
+		incidents  []EnrichedIncident
+		similarity float64
+		// want lists the incident IDs of each problem, largest first
+		want [][]string
+	}{
+		{
+			name: "transitive grouping",
+			// PINC1 and PINC3 are 40 minutes apart, but both are within the window of PINC2
+			incidents: []EnrichedIncident{
+				newCorrelated("PINC3", 40*time.Minute, "c-1", down),
+				newCorrelated("PINC1", 0, "c-1", down),
+				newCorrelated("PINC2", 20*time.Minute, "c-1", down),
+			},
+			want: [][]string{{"PINC1", "PINC2", "PINC3"}},
+		},
+		{
+			name: "at the window edge",
+			incidents: []EnrichedIncident{
+				newCorrelated("PINC1", 0, "c-1", down),
+				newCorrelated("PINC2", DefaultCorrelationWindow, "c-1", down),
+			},
+			want: [][]string{{"PINC1", "PINC2"}},
+		},
+		{
+			name: "just outside the window",
+			incidents: []EnrichedIncident{
+				newCorrelated("PINC1", 0, "c-1", down),
+				newCorrelated("PINC2", DefaultCorrelationWindow+time.Second, "c-1", down),
+			},
+			want: [][]string{{"PINC1"}, {"PINC2"}},
+		},
+		{
+			name: "similarity at the threshold",
+			incidents: []EnrichedIncident{
+				newCorrelated("PINC1", 0, "c-1", apiserver),
+				newCorrelated("PINC2", time.Minute, "c-1", budgetBurn),
+			},
+			similarity: 0.5,
+			want:       [][]string{{"PINC1", "PINC2"}},
+		},
+		{
+			name: "similarity below the threshold",
+			incidents: []EnrichedIncident{
+				newCorrelated("PINC1", 0, "c-1", apiserver),
+				newCorrelated("PINC2", time.Minute, "c-1", budgetBurn),
+			},
+			similarity: 0.51,
+			want:       [][]string{{"PINC1"}, {"PINC2"}},
+		},
+		{
+			name: "different clusters",
+			incidents: []EnrichedIncident{
+				newCorrelated("PINC1", 0, "c-1", down),
+				newCorrelated("PINC2", time.Minute, "c-2", down),
+			},
+			want: [][]string{{"PINC1"}, {"PINC2"}},
+		},
+		{
+			name: "unknown cluster",
+			incidents: []EnrichedIncident{
+				newCorrelated("PINC1", 0, UnknownCluster, down),
+				newCorrelated("PINC2", time.Minute, UnknownCluster, down),
+			},
+			want: [][]string{{"PINC1"}, {"PINC2"}},
+		},
+		{
+			name: "largest problem first",
+			incidents: []EnrichedIncident{
+				newCorrelated("PINC1", 0, "c-1", down),
+				newCorrelated("PINC2", time.Minute, "c-2", down),
+				newCorrelated("PINC3", 2*time.Minute, "c-2", down),
+			},
+			want: [][]string{{"PINC2", "PINC3"}, {"PINC1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := Correlate(tt.incidents, CorrelationOptions{Similarity: tt.similarity})
+
+			var got [][]string
+			for _, p := range problems {
+				got = append(got, p.IncidentIDs)
+
+				// The oldest incident is the primary one
+				if p.ID != p.IncidentIDs[0] || p.Primary.ID != p.ID || len(p.Duplicates) != len(p.IncidentIDs)-1 {
+					t.Errorf("problem %v has primary %v and %v duplicate(s), want %v and %v", p.ID, p.Primary.ID, len(p.Duplicates), p.IncidentIDs[0], len(p.IncidentIDs)-1)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Correlate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
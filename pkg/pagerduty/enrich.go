@@ -0,0 +1,174 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// DefaultEnrichWorkers is the number of incidents enriched concurrently when EnrichOptions.Workers is 0
+const DefaultEnrichWorkers = 8
+
+// EnrichedIncident is an incident along with its parsed alerts, notes, assigned users and service.
+// Fields that could not be fetched are left empty and the reason is given in Errors.
+type EnrichedIncident struct {
+	Incident  pagerduty.Incident       `json:"incident" yaml:"incident"`
+	Severity  string                   `json:"severity" yaml:"severity"`
+	Alerts    []Alert                  `json:"alerts" yaml:"alerts"`
+	Notes     []pagerduty.IncidentNote `json:"notes" yaml:"notes"`
+	Assignees []pagerduty.User         `json:"assignees" yaml:"assignees"`
+	Service   *pagerduty.Service       `json:"service,omitempty" yaml:"service,omitempty"`
+	Errors    []string                 `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// EnrichOptions tunes EnrichIncidents. The parsed alerts are always fetched, the other parts can be
+// skipped by callers that do not need them.
+type EnrichOptions struct {
+	// Workers bounds the number of incidents enriched concurrently
+	Workers int
+
+	WithoutNotes     bool
+	WithoutAssignees bool
+	WithoutService   bool
+}
+
+// EnrichIncidents calls EnrichIncidentsWithContext with a background context.
+func EnrichIncidents(client PagerDutyClient, incidents []pagerduty.Incident, opts EnrichOptions) ([]EnrichedIncident, error) {
+	return EnrichIncidentsWithContext(context.Background(), client, incidents, opts)
+}
+
+// EnrichIncidentsWithContext fetches the alerts, notes, assignees and service of each incident using a
+// bounded pool of workers, and returns the enriched incidents in the order they were given. A failure to
+// fetch part of an incident is recorded on that incident rather than aborting the others; an error is
+// only returned if ctx is done before every incident was enriched.
+func EnrichIncidentsWithContext(ctx context.Context, client PagerDutyClient, incidents []pagerduty.Incident, opts EnrichOptions) ([]EnrichedIncident, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultEnrichWorkers
+	}
+	workers = min(workers, len(incidents))
+
+	enriched := make([]EnrichedIncident, len(incidents))
+	lookups := &lookups{client: client}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				enriched[i] = enrichIncident(ctx, client, lookups, incidents[i], opts)
+			}
+		}()
+	}
+
+feed:
+	for i := range incidents {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return enriched, fmt.Errorf("pd.EnrichIncidents(): %v", err)
+	}
+
+	return enriched, nil
+}
+
+func enrichIncident(ctx context.Context, client PagerDutyClient, lookups *lookups, incident pagerduty.Incident, opts EnrichOptions) EnrichedIncident {
+	e := EnrichedIncident{Incident: incident, Alerts: []Alert{}, Notes: []pagerduty.IncidentNote{}, Assignees: []pagerduty.User{}}
+
+	fail := func(what string, err error) {
+		e.Errors = append(e.Errors, fmt.Sprintf("%v: %v", what, err))
+	}
+
+	severity, alerts, err := IncidentSeverityWithContext(ctx, client, incident.ID)
+	if err != nil {
+		fail("alerts", err)
+	} else {
+		e.Severity = severity
+		if alerts != nil {
+			e.Alerts = alerts
+		}
+		for _, a := range alerts {
+			if a.Partial {
+				fail("alert "+a.AlertID, fmt.Errorf("%v", a.ParseError))
+			}
+		}
+	}
+
+	if !opts.WithoutNotes {
+		notes, err := GetNotesWithContext(ctx, client, incident.ID)
+		if err != nil {
+			fail("notes", err)
+		} else if notes != nil {
+			e.Notes = notes
+		}
+	}
+
+	if !opts.WithoutAssignees {
+		for _, a := range incident.Assignments {
+			user, err := lookups.user(ctx, a.Assignee.ID)
+			if err != nil {
+				fail("assignee "+a.Assignee.ID, err)
+				continue
+			}
+			e.Assignees = append(e.Assignees, *user)
+		}
+	}
+
+	if !opts.WithoutService && incident.Service.ID != "" {
+		service, err := lookups.service(ctx, incident.Service.ID)
+		if err != nil {
+			fail("service", err)
+		} else {
+			e.Service = service
+		}
+	}
+
+	return e
+}
+
+// lookups fetches each user and service once per enrichment, however many incidents refer to it
+type lookups struct {
+	client PagerDutyClient
+
+	users    sync.Map // id -> *lookup[pagerduty.User]
+	services sync.Map // id -> *lookup[pagerduty.Service]
+}
+
+type lookup[T any] struct {
+	once  sync.Once
+	value *T
+	err   error
+}
+
+func (l *lookups) user(ctx context.Context, id string) (*pagerduty.User, error) {
+	v, _ := l.users.LoadOrStore(id, &lookup[pagerduty.User]{})
+	u := v.(*lookup[pagerduty.User])
+	u.once.Do(func() {
+		u.value, u.err = GetUserWithContext(ctx, l.client, id, pagerduty.GetUserOptions{})
+	})
+	return u.value, u.err
+}
+
+func (l *lookups) service(ctx context.Context, id string) (*pagerduty.Service, error) {
+	v, _ := l.services.LoadOrStore(id, &lookup[pagerduty.Service]{})
+	s := v.(*lookup[pagerduty.Service])
+	s.once.Do(func() {
+		s.value, s.err = l.client.GetServiceWithContext(ctx, id, &pagerduty.GetServiceOptions{})
+		if s.err != nil {
+			s.err = fmt.Errorf("pd.GetService(): failed to get service `%v`: %v", id, s.err)
+		}
+	})
+	return s.value, s.err
+}
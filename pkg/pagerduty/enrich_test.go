@@ -0,0 +1,173 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+// enrichClient records how many incidents are fetched at once and how often users are looked up, and
+// fails the alerts or notes of the given incidents
+type enrichClient struct {
+	*fake.Client
+
+	failAlerts, failNotes string
+
+	mu                    sync.Mutex
+	inFlight, maxInFlight int
+	users                 int
+}
+
+func (c *enrichClient) ListIncidentAlertsWithContext(ctx context.Context, id string, opts pagerduty.ListIncidentAlertsOptions) (*pagerduty.ListAlertsResponse, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.mu.Unlock()
+
+	// Long enough for the workers to overlap
+	time.Sleep(5 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+
+	if id == c.failAlerts {
+		return nil, pagerduty.APIError{StatusCode: http.StatusInternalServerError}
+	}
+	return c.Client.ListIncidentAlertsWithContext(ctx, id, opts)
+}
+
+func (c *enrichClient) ListIncidentNotesWithContext(ctx context.Context, id string) ([]pagerduty.IncidentNote, error) {
+	if id == c.failNotes {
+		return nil, pagerduty.APIError{StatusCode: http.StatusInternalServerError}
+	}
+	return c.Client.ListIncidentNotesWithContext(ctx, id)
+}
+
+func (c *enrichClient) GetUserWithContext(ctx context.Context, id string, opts pagerduty.GetUserOptions) (*pagerduty.User, error) {
+	c.mu.Lock()
+	c.users++
+	c.mu.Unlock()
+	return c.Client.GetUserWithContext(ctx, id, opts)
+}
+
+// newEnrichClient returns n incidents of the same service, assigned in turn to PUSER1 and PUSER2,
+// each with a critical alert and a note
+func newEnrichClient(n int) (*enrichClient, []pagerduty.Incident) {
+	c := fake.New()
+	c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER1"}, Name: "Jane Doe"})
+	c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER2"}, Name: "John Doe"})
+	c.AddService(pagerduty.Service{APIObject: pagerduty.APIObject{ID: "PSVC1"}, Name: "prod-east-1-hive-cluster", Description: "prod-east-1 cluster"})
+
+	var incidents []pagerduty.Incident
+	for i := 1; i <= n; i++ {
+		incident := pagerduty.Incident{
+			APIObject:   pagerduty.APIObject{ID: fmt.Sprintf("PINC%02d", i)},
+			Status:      "triggered",
+			Service:     pagerduty.APIObject{ID: "PSVC1"},
+			Assignments: []pagerduty.Assignment{{Assignee: pagerduty.APIObject{ID: fmt.Sprintf("PUSER%v", 1+i%2)}}},
+		}
+		c.AddIncident(incident, pagerduty.IncidentAlert{
+			APIObject: pagerduty.APIObject{ID: fmt.Sprintf("PALERT%02d", i)},
+			Severity:  "critical",
+			Body:      map[string]interface{}{"details": map[string]interface{}{"cluster_id": "c-123"}},
+		})
+		c.AddNote(incident.ID, pagerduty.IncidentNote{Content: "Looking into it"})
+		incidents = append(incidents, incident)
+	}
+
+	return &enrichClient{Client: c}, incidents
+}
+
+func TestEnrichIncidents(t *testing.T) {
+	c, incidents := newEnrichClient(12)
+	c.failAlerts, c.failNotes = "PINC03", "PINC05"
+	// An assignee that cannot be looked up
+	incidents[6].Assignments = append(incidents[6].Assignments, pagerduty.Assignment{Assignee: pagerduty.APIObject{ID: "PNOPE"}})
+
+	enriched, err := EnrichIncidentsWithContext(context.Background(), c, incidents, EnrichOptions{Workers: 3})
+	if err != nil {
+		t.Fatalf("EnrichIncidents() error = %v", err)
+	}
+
+	if c.maxInFlight > 3 {
+		t.Errorf("%v incidents enriched at once, want at most 3", c.maxInFlight)
+	}
+	// Each user is looked up once, whichever incident needs it first
+	if c.users != 3 {
+		t.Errorf("looked up %v user(s), want 3", c.users)
+	}
+
+	if len(enriched) != len(incidents) {
+		t.Fatalf("got %v incidents, want %v", len(enriched), len(incidents))
+	}
+	for i, e := range enriched {
+		if e.Incident.ID != incidents[i].ID {
+			t.Fatalf("incident %v is %v, want the order they were given in", i, e.Incident.ID)
+		}
+
+		var wantErrors []string
+		switch e.Incident.ID {
+		case "PINC03":
+			wantErrors = []string{"alerts: "}
+		case "PINC05":
+			wantErrors = []string{"notes: "}
+		case "PINC07":
+			wantErrors = []string{"assignee PNOPE: "}
+		}
+		if len(e.Errors) != len(wantErrors) || (len(wantErrors) > 0 && !strings.HasPrefix(e.Errors[0], wantErrors[0])) {
+			t.Errorf("%v errors = %q, want %q", e.Incident.ID, e.Errors, wantErrors)
+		}
+
+		// The parts that could be fetched are still there
+		if e.Incident.ID != "PINC03" && (len(e.Alerts) != 1 || e.Severity != SeverityCritical) {
+			t.Errorf("%v has %v alert(s) of severity %q, want 1 critical", e.Incident.ID, len(e.Alerts), e.Severity)
+		}
+		if e.Incident.ID != "PINC05" && len(e.Notes) != 1 {
+			t.Errorf("%v has %v note(s), want 1", e.Incident.ID, len(e.Notes))
+		}
+		if len(e.Assignees) != 1 || e.Service == nil || e.Service.ID != "PSVC1" {
+			t.Errorf("%v assignees = %+v, service = %+v, want one assignee and PSVC1", e.Incident.ID, e.Assignees, e.Service)
+		}
+	}
+}
+
+func TestEnrichIncidentsOptions(t *testing.T) {
+	c, incidents := newEnrichClient(4)
+
+	enriched, err := EnrichIncidentsWithContext(context.Background(), c, incidents, EnrichOptions{WithoutNotes: true, WithoutAssignees: true, WithoutService: true})
+	if err != nil {
+		t.Fatalf("EnrichIncidents() error = %v", err)
+	}
+
+	if c.users != 0 {
+		t.Errorf("looked up %v user(s), want none", c.users)
+	}
+	for _, e := range enriched {
+		if len(e.Alerts) != 1 || len(e.Notes) != 0 || len(e.Assignees) != 0 || e.Service != nil || len(e.Errors) != 0 {
+			t.Errorf("%v = %+v, want its alerts only", e.Incident.ID, e)
+		}
+	}
+}
+
+func TestEnrichIncidentsCancelled(t *testing.T) {
+	c, incidents := newEnrichClient(4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	enriched, err := EnrichIncidentsWithContext(ctx, c, incidents, EnrichOptions{Workers: 1})
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("EnrichIncidents() error = %v, want the cancellation", err)
+	}
+	if len(enriched) != len(incidents) {
+		t.Errorf("got %v incidents, want one per incident given", len(enriched))
+	}
+}
@@ -0,0 +1,107 @@
+package pd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrFieldMissing is reported for alert fields absent from the alert details
+	ErrFieldMissing = errors.New("missing")
+	// ErrFieldMistyped is reported for alert fields whose value does not have the expected type or format
+	ErrFieldMistyped = errors.New("unexpected type")
+)
+
+// FieldError describes an alert detail an alert parser could not read.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("field `%v`: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError is returned by ParseAlertData when an alert could only be partially parsed.
+// The fields that could be read are still filled in. Use errors.As to get the FieldErrors.
+type ParseError struct {
+	AlertID string
+	Parser  string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	if e.Parser == "" {
+		return fmt.Sprintf("pd.ParseAlertData(): failed to parse alert `%v`: %v", e.AlertID, e.Err)
+	}
+	return fmt.Sprintf("pd.ParseAlertData(): failed to parse alert `%v` with parser `%v`: %v", e.AlertID, e.Parser, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// detailReader reads values out of alert details, collecting a FieldError for each value it cannot read
+// so that extractors can fill in every field they can before reporting what went wrong
+type detailReader struct {
+	details map[string]interface{}
+	errs    []error
+}
+
+// string returns the string value of the key, or "" if it is missing or not a string
+func (r *detailReader) string(key string) string {
+	v, ok := r.details[key]
+	if !ok || v == nil {
+		r.fail(key, ErrFieldMissing)
+		return ""
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		r.fail(key, fmt.Errorf("%w: %T", ErrFieldMistyped, v))
+		return ""
+	}
+
+	return s
+}
+
+// optional returns the value of the key formatted as a string, or "" if it is missing
+func (r *detailReader) optional(key string) string {
+	v, ok := r.details[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func (r *detailReader) fail(field string, err error) {
+	r.errs = append(r.errs, &FieldError{Field: field, Err: err})
+}
+
+// err returns the collected field errors, or nil if every value could be read
+func (r *detailReader) err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+	return fieldErrors(r.errs)
+}
+
+// fieldErrors joins several field errors on a single line, so they fit in a table cell
+type fieldErrors []error
+
+func (e fieldErrors) Error() string {
+	var msgs []string
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e fieldErrors) Unwrap() []error {
+	return e
+}
@@ -0,0 +1,175 @@
+package fake
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// IDs of the objects seeded by NewDemo, to build a matching configuration
+const (
+	DemoTeamID             = "PDEMOTM"
+	DemoSilentUserID       = "PSILENT"
+	DemoEscalationPolicyID = "PDEMOEP"
+)
+
+const demoRunbook = "https://github.com/openshift/ops-sop/blob/master/v4/alerts/ClusterHasGoneMissing.md"
+
+// NewDemo returns a client seeded with a small on-call team and a queue of realistic incidents
+// covering each of the alert shapes the pd package knows how to parse.
+func NewDemo() *Client {
+	c := New()
+	now := time.Now().UTC()
+
+	users := []pagerduty.User{
+		{APIObject: pagerduty.APIObject{ID: "PDEMO01"}, Name: "Demo User", Email: "demo@example.com"},
+		{APIObject: pagerduty.APIObject{ID: "PDEMO02"}, Name: "Ada Lovelace", Email: "ada@example.com"},
+		{APIObject: pagerduty.APIObject{ID: "PDEMO03"}, Name: "Grace Hopper", Email: "grace@example.com"},
+		{APIObject: pagerduty.APIObject{ID: DemoSilentUserID}, Name: "Silent Test", Email: "silent@example.com"},
+	}
+	for _, u := range users {
+		c.AddUser(u)
+	}
+	c.SetCurrentUser("PDEMO01")
+
+	c.AddTeam(pagerduty.Team{APIObject: pagerduty.APIObject{ID: DemoTeamID}, Name: "Demo SRE", Description: "Offline demo team"},
+		"PDEMO01", "PDEMO02", "PDEMO03")
+
+	c.AddService(pagerduty.Service{APIObject: pagerduty.APIObject{ID: "PSVC001"}, Name: "prod-east-1-hive-cluster", Description: "prod-east-1 production cluster"})
+	c.AddService(pagerduty.Service{APIObject: pagerduty.APIObject{ID: "PSVC002"}, Name: "stage-west-2-hive-cluster", Description: "stage-west-2 staging cluster"})
+	c.AddService(pagerduty.Service{APIObject: pagerduty.APIObject{ID: "PSVC003"}, Name: "Dead Man's Snitch", Description: "cluster heartbeats"})
+	c.AddService(pagerduty.Service{APIObject: pagerduty.APIObject{ID: "PSVC004"}, Name: "Certificate monitoring", Description: "certificate expiry checks"})
+
+	for _, p := range []string{"P1", "P2", "P3", "P4", "P5"} {
+		c.AddPriority(pagerduty.Priority{APIObject: pagerduty.APIObject{ID: "PPRIO" + p[1:]}, Name: p})
+	}
+
+	// The demo user is on call first, then Ada, then Grace
+	policy := pagerduty.EscalationPolicy{APIObject: pagerduty.APIObject{ID: DemoEscalationPolicyID, Type: "escalation_policy_reference", Summary: "Demo SRE"}, Name: "Demo SRE"}
+	for level, id := range []string{"PDEMO01", "PDEMO02", "PDEMO03"} {
+		c.AddOnCall(pagerduty.OnCall{User: pagerduty.User{APIObject: pagerduty.APIObject{ID: id}}, EscalationPolicy: policy, EscalationLevel: uint(level + 1)})
+	}
+
+	ts := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+
+	assigned := func(id string) []pagerduty.Assignment {
+		return []pagerduty.Assignment{{At: ts(time.Hour), Assignee: pagerduty.APIObject{ID: id, Type: "user_reference", Summary: userName(users, id)}}}
+	}
+
+	service := func(id string) pagerduty.APIObject {
+		return pagerduty.APIObject{ID: id, Type: "service_reference", Summary: c.services[id].Name}
+	}
+
+	incident := func(n uint, title, status, urgency, serviceID, userID string, ago time.Duration) pagerduty.Incident {
+		id := fmt.Sprintf("PINC%03d", n)
+		i := pagerduty.Incident{
+			APIObject:          pagerduty.APIObject{ID: id, HTMLURL: "https://example.pagerduty.com/incidents/" + id},
+			IncidentNumber:     n,
+			Title:              title,
+			Status:             status,
+			Urgency:            urgency,
+			CreatedAt:          ts(ago),
+			LastStatusChangeAt: ts(ago),
+			Service:            service(serviceID),
+			Assignments:        assigned(userID),
+			EscalationPolicy:   policy.APIObject,
+			Teams:              []pagerduty.APIObject{{ID: DemoTeamID, Type: "team_reference", Summary: "Demo SRE"}},
+		}
+		if status == "acknowledged" {
+			i.Acknowledgements = []pagerduty.Acknowledgement{{At: ts(ago - 4*time.Minute), Acknowledger: i.Assignments[0].Assignee}}
+		}
+		return i
+	}
+
+	alert := func(id, summary, severity string, ago time.Duration, details map[string]interface{}) pagerduty.IncidentAlert {
+		return pagerduty.IncidentAlert{
+			APIObject: pagerduty.APIObject{ID: id, Summary: summary, HTMLURL: "https://example.pagerduty.com/alerts/" + id},
+			CreatedAt: ts(ago),
+			Severity:  severity,
+			Body:      map[string]interface{}{"details": details},
+		}
+	}
+
+	// Default Alertmanager shaped alerts
+	c.AddIncident(incident(101, "[FIRING:1] KubeAPIErrorBudgetBurn prod-east-1", "triggered", "high", "PSVC001", "PDEMO01", 25*time.Minute),
+		alert("PALT101", "KubeAPIErrorBudgetBurn", "critical", 25*time.Minute, map[string]interface{}{
+			"cluster_id": "1a2b3c4d-0000-4000-8000-000000000101",
+			"console":    "https://console-openshift-console.apps.prod-east-1.example.com",
+			"firing":     "Labels:\n - alertname = KubeAPIErrorBudgetBurn\n - namespace = openshift-kube-apiserver\n - severity = critical\nAnnotations:\n - summary = The API server is burning too much error budget.\n",
+			"link":       "https://github.com/openshift/runbooks/blob/master/alerts/cluster-kube-apiserver-operator/KubeAPIErrorBudgetBurn.md",
+		}),
+	)
+
+	// A second page for the same burn, as alert storms produce, for problem correlation
+	c.AddIncident(incident(106, "[FIRING:1] KubeAPIErrorBudgetBurn prod-east-1", "triggered", "high", "PSVC001", "PDEMO03", 21*time.Minute),
+		alert("PALT107", "KubeAPIErrorBudgetBurn", "critical", 21*time.Minute, map[string]interface{}{
+			"cluster_id": "1a2b3c4d-0000-4000-8000-000000000101",
+			"console":    "https://console-openshift-console.apps.prod-east-1.example.com",
+			"firing":     "Labels:\n - alertname = KubeAPIErrorBudgetBurn\n - long = 6h\n - namespace = openshift-kube-apiserver\n - severity = critical\nAnnotations:\n - summary = The API server is burning too much error budget.\n",
+			"link":       "https://github.com/openshift/runbooks/blob/master/alerts/cluster-kube-apiserver-operator/KubeAPIErrorBudgetBurn.md",
+		}),
+	)
+
+	c.AddIncident(incident(102, "[FIRING:2] etcdMembersDown prod-east-1", "acknowledged", "high", "PSVC001", "PDEMO02", 2*time.Hour),
+		alert("PALT102", "etcdMembersDown", "critical", 2*time.Hour, map[string]interface{}{
+			"cluster_id": "1a2b3c4d-0000-4000-8000-000000000101",
+			"console":    "https://console-openshift-console.apps.prod-east-1.example.com",
+			"firing":     "Labels:\n - alertname = etcdMembersDown\n - namespace = openshift-etcd\n - severity = critical\n",
+			"link":       "https://github.com/openshift/runbooks/blob/master/alerts/cluster-etcd-operator/etcdMembersDown.md",
+		}),
+		alert("PALT103", "etcdNoLeader", "critical", 110*time.Minute, map[string]interface{}{
+			"cluster_id": "1a2b3c4d-0000-4000-8000-000000000101",
+			"console":    "https://console-openshift-console.apps.prod-east-1.example.com",
+			"firing":     "Labels:\n - alertname = etcdNoLeader\n - namespace = openshift-etcd\n - severity = critical\n",
+			"link":       "https://github.com/openshift/runbooks/blob/master/alerts/cluster-etcd-operator/etcdNoLeader.md",
+		}),
+	)
+
+	c.AddIncident(incident(103, "[FIRING:1] ClusterOperatorDegraded stage-west-2", "triggered", "low", "PSVC002", "PDEMO03", 6*time.Hour),
+		alert("PALT104", "ClusterOperatorDegraded", "warning", 6*time.Hour, map[string]interface{}{
+			"cluster_id": "5e6f7a8b-0000-4000-8000-000000000103",
+			"console":    "https://console-openshift-console.apps.stage-west-2.example.com",
+			"firing":     "Labels:\n - alertname = ClusterOperatorDegraded\n - name = ingress\n - severity = warning\n",
+			"link":       "https://github.com/openshift/runbooks/blob/master/alerts/cluster-version-operator/ClusterOperatorDegraded.md",
+		}),
+	)
+
+	// Cluster has gone missing, reported by Dead Man's Snitch
+	c.AddIncident(incident(104, "prod-north-3 has gone missing", "triggered", "high", "PSVC003", "PDEMO01", 12*time.Minute),
+		alert("PALT105", "prod-north-3 has gone missing", "critical", 12*time.Minute, map[string]interface{}{
+			"notes":                 "cluster_id: 9c0d1e2f-0000-4000-8000-000000000104\nrunbook: " + demoRunbook,
+			"name":                  "prod-north-3.a1b2.p1.openshiftapps.com",
+			"last healthy check-in": now.Add(-20 * time.Minute).Format("2006-01-02T15:04:05Z"),
+			"token":                 "demo-snitch-token",
+			"tags":                  "hive, production",
+		}),
+	)
+
+	// Certificate expiring on a host outside any cluster
+	c.AddIncident(incident(105, "Certificate is expiring on api.example.com", "acknowledged", "low", "PSVC004", "PDEMO02", 26*time.Hour),
+		alert("PALT106", "Certificate is expiring on api.example.com", "warning", 26*time.Hour, map[string]interface{}{
+			"hostname": "api.example.com",
+			"ip":       "192.0.2.10",
+			"url":      "https://github.com/openshift/ops-sop/blob/master/v4/alerts/CertificateExpiring.md",
+		}),
+	)
+
+	c.AddNote("PINC102", pagerduty.IncidentNote{
+		ID:        "PNOTE01",
+		User:      pagerduty.APIObject{ID: "PDEMO02", Type: "user_reference", Summary: "Ada Lovelace"},
+		Content:   "Looking into it, one etcd member lost its disk.",
+		CreatedAt: ts(90 * time.Minute),
+	})
+
+	return c
+}
+
+func userName(users []pagerduty.User, id string) string {
+	for _, u := range users {
+		if u.ID == id {
+			return u.Name
+		}
+	}
+	return ""
+}
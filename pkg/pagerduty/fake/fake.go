@@ -0,0 +1,848 @@
+// Package fake provides an in-memory stand-in for the PagerDuty API implementing pd.PagerDutyClient,
+// for use in tests and in the offline demo mode of the CLI.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// defaultLimit is the page size PagerDuty uses when a request doesn't set one
+const defaultLimit = 25
+
+// maxManageIncidents is the most incidents PagerDuty accepts in a single ManageIncidents request
+const maxManageIncidents = 250
+
+// Client holds incidents, alerts, notes, log entries, teams, users, services, priorities and on-call
+// entries in memory. Changes made through it are recorded in the log entries of their incidents. It is safe for
+// concurrent use.
+type Client struct {
+	mu sync.Mutex
+
+	currentUserID string
+	incidents     []*pagerduty.Incident
+	alerts        map[string][]pagerduty.IncidentAlert
+	notes         map[string][]pagerduty.IncidentNote
+	logEntries    map[string][]pagerduty.LogEntry
+	teams         map[string]*pagerduty.Team
+	members       map[string][]string
+	users         map[string]*pagerduty.User
+	services      map[string]*pagerduty.Service
+	priorities    []pagerduty.Priority
+	oncalls       []pagerduty.OnCall
+
+	nextID int
+
+	// Now returns the time used for timestamps on notes, acknowledgements and status changes
+	Now func() time.Time
+}
+
+func New() *Client {
+	return &Client{
+		alerts:     map[string][]pagerduty.IncidentAlert{},
+		notes:      map[string][]pagerduty.IncidentNote{},
+		logEntries: map[string][]pagerduty.LogEntry{},
+		teams:      map[string]*pagerduty.Team{},
+		members:    map[string][]string{},
+		users:      map[string]*pagerduty.User{},
+		services:   map[string]*pagerduty.Service{},
+		Now:        time.Now,
+	}
+}
+
+// AddUser stores a user. The first user added is the current user unless SetCurrentUser says otherwise.
+func (c *Client) AddUser(user pagerduty.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if user.Type == "" {
+		user.Type = "user"
+	}
+	if user.Summary == "" {
+		user.Summary = user.Name
+	}
+	c.users[user.ID] = &user
+
+	if c.currentUserID == "" {
+		c.currentUserID = user.ID
+	}
+}
+
+// SetCurrentUser sets the user returned by GetCurrentUserWithContext.
+func (c *Client) SetCurrentUser(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.currentUserID = id
+}
+
+// AddTeam stores a team and the IDs of its members.
+func (c *Client) AddTeam(team pagerduty.Team, memberIDs ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if team.Type == "" {
+		team.Type = "team"
+	}
+	if team.Summary == "" {
+		team.Summary = team.Name
+	}
+	c.teams[team.ID] = &team
+	c.members[team.ID] = append([]string{}, memberIDs...)
+}
+
+func (c *Client) AddService(service pagerduty.Service) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if service.Type == "" {
+		service.Type = "service"
+	}
+	if service.Summary == "" {
+		service.Summary = service.Name
+	}
+	c.services[service.ID] = &service
+}
+
+// AddPriority stores a priority. Priorities are listed in the order they were added, highest first.
+func (c *Client) AddPriority(priority pagerduty.Priority) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if priority.Type == "" {
+		priority.Type = "priority"
+	}
+	if priority.Summary == "" {
+		priority.Summary = priority.Name
+	}
+	c.priorities = append(c.priorities, priority)
+}
+
+// AddOnCall stores an on-call entry, placing a user at a level of an escalation policy. Escalating
+// an incident to a level assigns it to the users on call at that level of its escalation policy.
+func (c *Client) AddOnCall(oncall pagerduty.OnCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if user, ok := c.users[oncall.User.ID]; ok {
+		oncall.User = *user
+	}
+	c.oncalls = append(c.oncalls, oncall)
+}
+
+// AddIncident stores an incident and its alerts. Alerts are linked to the incident and its service.
+// The incident is logged as triggered when it was created, and acknowledged by its acknowledgers.
+func (c *Client) AddIncident(incident pagerduty.Incident, alerts ...pagerduty.IncidentAlert) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if incident.Type == "" {
+		incident.Type = "incident"
+	}
+	if incident.Summary == "" {
+		incident.Summary = incident.Title
+	}
+
+	var triggered uint
+	for i := range alerts {
+		alerts[i].Incident = pagerduty.APIReference{ID: incident.ID, Type: "incident_reference"}
+		if alerts[i].Service.ID == "" {
+			alerts[i].Service = incident.Service
+		}
+		if alerts[i].Status == "" || alerts[i].Status == "triggered" {
+			alerts[i].Status = "triggered"
+			triggered++
+		}
+	}
+	incident.AlertCounts = pagerduty.AlertCounts{Triggered: triggered, Resolved: uint(len(alerts)) - triggered, All: uint(len(alerts))}
+
+	c.incidents = append(c.incidents, &incident)
+	c.alerts[incident.ID] = append(c.alerts[incident.ID], alerts...)
+
+	c.log(&incident, "trigger", incident.Service, incident.CreatedAt, "Triggered through the API")
+	for _, a := range incident.Acknowledgements {
+		c.log(&incident, "acknowledge", a.Acknowledger, a.At, "Acknowledged by "+a.Acknowledger.Summary)
+	}
+}
+
+// AddNote stores a note on an incident as is, e.g. to seed notes written in the past.
+func (c *Client) AddNote(incidentID string, note pagerduty.IncidentNote) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notes[incidentID] = append(c.notes[incidentID], note)
+	if incident := c.incident(incidentID); incident != nil {
+		c.log(incident, "annotate", note.User, note.CreatedAt, "Note added")
+	}
+}
+
+// AddLogEntry stores a log entry of an incident as is, in addition to those the client records.
+func (c *Client) AddLogEntry(incidentID string, entry pagerduty.LogEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.logEntries[incidentID] = append(c.logEntries[incidentID], entry)
+}
+
+func (c *Client) CreateIncidentNoteWithContext(ctx context.Context, id string, note pagerduty.IncidentNote) (*pagerduty.IncidentNote, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.incident(id) == nil {
+		return nil, notFound("incident", id)
+	}
+
+	note.ID = c.newID("N")
+	note.CreatedAt = c.timestamp()
+	if user, ok := c.users[note.User.ID]; ok {
+		note.User = user.APIObject
+	}
+
+	c.notes[id] = append(c.notes[id], note)
+	c.log(c.incident(id), "annotate", note.User, note.CreatedAt, "Note added")
+	return &note, nil
+}
+
+func (c *Client) GetCurrentUserWithContext(ctx context.Context, opts pagerduty.GetCurrentUserOptions) (*pagerduty.User, error) {
+	return c.GetUserWithContext(ctx, c.currentUser(), pagerduty.GetUserOptions{})
+}
+
+func (c *Client) GetIncidentWithContext(ctx context.Context, id string) (*pagerduty.Incident, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	incident := c.incident(id)
+	if incident == nil {
+		return nil, notFound("incident", id)
+	}
+
+	i := *incident
+	return &i, nil
+}
+
+func (c *Client) GetServiceWithContext(ctx context.Context, serviceID string, opts *pagerduty.GetServiceOptions) (*pagerduty.Service, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	service, ok := c.services[serviceID]
+	if !ok {
+		return nil, notFound("service", serviceID)
+	}
+
+	s := *service
+	return &s, nil
+}
+
+func (c *Client) GetTeamWithContext(ctx context.Context, id string) (*pagerduty.Team, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	team, ok := c.teams[id]
+	if !ok {
+		return nil, notFound("team", id)
+	}
+
+	t := *team
+	return &t, nil
+}
+
+func (c *Client) ListMembersWithContext(ctx context.Context, id string, opts pagerduty.ListTeamMembersOptions) (*pagerduty.ListTeamMembersResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := c.teams[id]; !ok {
+		return nil, notFound("team", id)
+	}
+
+	var members []pagerduty.Member
+	for _, userID := range c.members[id] {
+		m := pagerduty.Member{User: pagerduty.APIObject{ID: userID, Type: "user_reference"}, Role: "responder"}
+		if user, ok := c.users[userID]; ok {
+			m.User.Summary = user.Name
+		}
+		members = append(members, m)
+	}
+
+	page, list := paginate(members, opts.Limit, opts.Offset)
+	return &pagerduty.ListTeamMembersResponse{APIListObject: list, Members: page}, nil
+}
+
+func (c *Client) GetUserWithContext(ctx context.Context, id string, opts pagerduty.GetUserOptions) (*pagerduty.User, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	user, ok := c.users[id]
+	if !ok {
+		return nil, notFound("user", id)
+	}
+
+	u := *user
+	return &u, nil
+}
+
+func (c *Client) ListIncidentAlertsWithContext(ctx context.Context, id string, opts pagerduty.ListIncidentAlertsOptions) (*pagerduty.ListAlertsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.incident(id) == nil {
+		return nil, notFound("incident", id)
+	}
+
+	var alerts []pagerduty.IncidentAlert
+	for _, a := range c.alerts[id] {
+		if len(opts.Statuses) == 0 || contains(opts.Statuses, a.Status) {
+			alerts = append(alerts, a)
+		}
+	}
+
+	page, list := paginate(alerts, opts.Limit, opts.Offset)
+	return &pagerduty.ListAlertsResponse{APIListObject: list, Alerts: page}, nil
+}
+
+// ListIncidentsWithContext filters the incidents on statuses, urgencies, assigned users, services,
+// teams and the since/until window, like the PagerDuty API does.
+func (c *Client) ListIncidentsWithContext(ctx context.Context, opts pagerduty.ListIncidentsOptions) (*pagerduty.ListIncidentsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var incidents []pagerduty.Incident
+	for _, incident := range c.incidents {
+		if c.matches(incident, opts) {
+			incidents = append(incidents, *incident)
+		}
+	}
+
+	page, list := paginate(incidents, opts.Limit, opts.Offset)
+	return &pagerduty.ListIncidentsResponse{APIListObject: list, Incidents: page}, nil
+}
+
+// ListIncidentLogEntriesWithContext returns the log entries of an incident oldest first, filtered on
+// the since/until window.
+func (c *Client) ListIncidentLogEntriesWithContext(ctx context.Context, id string, opts pagerduty.ListIncidentLogEntriesOptions) (*pagerduty.ListIncidentLogEntriesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.incident(id) == nil {
+		return nil, notFound("incident", id)
+	}
+
+	since, _ := time.Parse(time.RFC3339, opts.Since)
+	until, _ := time.Parse(time.RFC3339, opts.Until)
+
+	var entries []pagerduty.LogEntry
+	for _, e := range c.logEntries[id] {
+		at, err := time.Parse(time.RFC3339, e.CreatedAt)
+		if err == nil && ((!since.IsZero() && at.Before(since)) || (!until.IsZero() && !at.Before(until))) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt < entries[j].CreatedAt })
+
+	page, list := paginate(entries, opts.Limit, opts.Offset)
+	return &pagerduty.ListIncidentLogEntriesResponse{APIListObject: list, LogEntries: page}, nil
+}
+
+func (c *Client) ListIncidentNotesWithContext(ctx context.Context, id string) ([]pagerduty.IncidentNote, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.incident(id) == nil {
+		return nil, notFound("incident", id)
+	}
+
+	return append([]pagerduty.IncidentNote{}, c.notes[id]...), nil
+}
+
+// ListOnCallsWithContext filters the on-call entries on users and escalation policies.
+func (c *Client) ListOnCallsWithContext(ctx context.Context, opts pagerduty.ListOnCallOptions) (*pagerduty.ListOnCallsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var oncalls []pagerduty.OnCall
+	for _, o := range c.oncalls {
+		if len(opts.UserIDs) > 0 && !contains(opts.UserIDs, o.User.ID) {
+			continue
+		}
+		if len(opts.EscalationPolicyIDs) > 0 && !contains(opts.EscalationPolicyIDs, o.EscalationPolicy.ID) {
+			continue
+		}
+		oncalls = append(oncalls, o)
+	}
+
+	page, list := paginate(oncalls, opts.Limit, opts.Offset)
+	return &pagerduty.ListOnCallsResponse{APIListObject: list, OnCalls: page}, nil
+}
+
+func (c *Client) ListPrioritiesWithContext(ctx context.Context, opts pagerduty.ListPrioritiesOptions) (*pagerduty.ListPrioritiesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	page, list := paginate(c.priorities, opts.Limit, opts.Offset)
+	return &pagerduty.ListPrioritiesResponse{APIListObject: list, Priorities: page}, nil
+}
+
+// ManageIncidentsWithContext applies status, assignment, escalation and priority changes to
+// the incidents. Like the PagerDuty API, no incident is changed if any change is invalid.
+func (c *Client) ManageIncidentsWithContext(ctx context.Context, email string, opts []pagerduty.ManageIncidentsOptions) (*pagerduty.ListIncidentsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	from := c.userByEmail(email)
+	if from == nil {
+		return nil, fmt.Errorf("user with email `%v`: %w", email, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+
+	if len(opts) > maxManageIncidents {
+		return nil, fmt.Errorf("at most %v incidents can be managed at once: %w", maxManageIncidents, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+
+	for _, o := range opts {
+		if err := c.validate(o); err != nil {
+			return nil, err
+		}
+	}
+
+	now := c.timestamp()
+	var updated []pagerduty.Incident
+
+	for _, o := range opts {
+		incident := c.incident(o.ID)
+
+		if len(o.Assignments) > 0 {
+			incident.Assignments = nil
+			for _, a := range o.Assignments {
+				assignee := a.Assignee
+				if user, ok := c.users[assignee.ID]; ok {
+					assignee = user.APIObject
+				}
+				incident.Assignments = append(incident.Assignments, pagerduty.Assignment{At: now, Assignee: assignee})
+			}
+			c.log(incident, "assign", from.APIObject, now, "Assigned to "+assignees(incident))
+
+			// Reassigning an incident triggers it for the new assignees
+			if o.Status == "" {
+				c.setStatus(incident, "triggered", from, now)
+			}
+		}
+
+		if o.EscalationLevel > 0 {
+			incident.Assignments = nil
+			for _, oncall := range c.oncallsAt(incident.EscalationPolicy.ID, o.EscalationLevel) {
+				incident.Assignments = append(incident.Assignments, pagerduty.Assignment{At: now, Assignee: oncall.User.APIObject})
+			}
+			c.log(incident, "escalate", from.APIObject, now, fmt.Sprintf("Escalated to level %v, assigned to %v", o.EscalationLevel, assignees(incident)))
+			c.setStatus(incident, "triggered", from, now)
+		}
+
+		if o.Priority != nil {
+			priority := *c.priority(o.Priority.ID)
+			incident.Priority = &priority
+			incident.UpdatedAt = now
+			c.log(incident, "priority_change", from.APIObject, now, "Priority changed to "+priority.Name)
+		}
+
+		if o.Status != "" {
+			c.setStatus(incident, o.Status, from, now)
+		}
+
+		updated = append(updated, *incident)
+	}
+
+	return &pagerduty.ListIncidentsResponse{Incidents: updated}, nil
+}
+
+// MergeIncidentsWithContext moves the alerts of the source incidents to the incident with the given
+// ID and resolves the source incidents. Like the PagerDuty API, nothing is merged if any of the
+// incidents is unknown.
+func (c *Client) MergeIncidentsWithContext(ctx context.Context, from, id string, sourceIncidents []pagerduty.MergeIncidentsOptions) (*pagerduty.Incident, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	by := c.userByEmail(from)
+	if by == nil {
+		return nil, fmt.Errorf("user with email `%v`: %w", from, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+
+	target := c.incident(id)
+	if target == nil {
+		return nil, notFound("incident", id)
+	}
+	for _, s := range sourceIncidents {
+		if s.ID == id {
+			return nil, fmt.Errorf("incident `%v` cannot be merged into itself: %w", id, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+		}
+		if c.incident(s.ID) == nil {
+			return nil, notFound("incident", s.ID)
+		}
+	}
+
+	now := c.timestamp()
+
+	for _, s := range sourceIncidents {
+		source := c.incident(s.ID)
+
+		for _, alert := range c.alerts[s.ID] {
+			alert.Incident = pagerduty.APIReference{ID: id, Type: "incident_reference"}
+			c.alerts[id] = append(c.alerts[id], alert)
+			if alert.Status == "triggered" {
+				target.AlertCounts.Triggered++
+			} else {
+				target.AlertCounts.Resolved++
+			}
+			target.AlertCounts.All++
+		}
+		delete(c.alerts, s.ID)
+		source.AlertCounts = pagerduty.AlertCounts{}
+
+		c.setStatus(source, "resolved", by, now)
+		c.log(target, "merge", by.APIObject, now, "Merged "+s.ID)
+	}
+
+	target.UpdatedAt = now
+
+	merged := *target
+	return &merged, nil
+}
+
+// SnoozeIncidentWithContext holds off an acknowledged incident for duration seconds, after which it
+// would be triggered again. The pending unacknowledgement is reported in its pending actions.
+func (c *Client) SnoozeIncidentWithContext(ctx context.Context, from, id string, duration uint) (*pagerduty.Incident, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	by := c.userByEmail(from)
+	if by == nil {
+		return nil, fmt.Errorf("user with email `%v`: %w", from, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+
+	incident := c.incident(id)
+	if incident == nil {
+		return nil, notFound("incident", id)
+	}
+	if incident.Status != "acknowledged" {
+		return nil, fmt.Errorf("incident `%v` is %v, only acknowledged incidents can be snoozed: %w", id, incident.Status, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+	if duration == 0 {
+		return nil, fmt.Errorf("snooze duration must be positive: %w", pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+
+	now := c.Now().UTC()
+	var actions []pagerduty.PendingAction
+	for _, a := range incident.PendingActions {
+		if a.Type != "unacknowledge" {
+			actions = append(actions, a)
+		}
+	}
+	incident.PendingActions = append(actions, pagerduty.PendingAction{
+		Type: "unacknowledge",
+		At:   now.Add(time.Duration(duration) * time.Second).Format(time.RFC3339),
+	})
+	incident.UpdatedAt = now.Format(time.RFC3339)
+
+	c.log(incident, "snooze", by.APIObject, incident.UpdatedAt, fmt.Sprintf("Snoozed for %v", time.Duration(duration)*time.Second))
+
+	i := *incident
+	return &i, nil
+}
+
+// UpdateIncidentUrgencyWithContext sets the urgency of an incident, high or low.
+func (c *Client) UpdateIncidentUrgencyWithContext(ctx context.Context, from, id, urgency string) (*pagerduty.Incident, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	by := c.userByEmail(from)
+	if by == nil {
+		return nil, fmt.Errorf("user with email `%v`: %w", from, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+
+	incident := c.incident(id)
+	if incident == nil {
+		return nil, notFound("incident", id)
+	}
+	if urgency != "high" && urgency != "low" {
+		return nil, fmt.Errorf("urgency `%v` is neither high nor low: %w", urgency, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+
+	incident.Urgency = urgency
+	incident.UpdatedAt = c.timestamp()
+	c.log(incident, "urgency_change", by.APIObject, incident.UpdatedAt, "Urgency changed to "+urgency)
+
+	i := *incident
+	return &i, nil
+}
+
+// validate rejects the changes PagerDuty would refuse
+func (c *Client) validate(o pagerduty.ManageIncidentsOptions) error {
+	incident := c.incident(o.ID)
+	if incident == nil {
+		return notFound("incident", o.ID)
+	}
+
+	if o.Priority != nil && c.priority(o.Priority.ID) == nil {
+		return fmt.Errorf("priority `%v` does not exist: %w", o.Priority.ID, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+
+	if o.EscalationLevel > 0 && len(c.oncallsAt(incident.EscalationPolicy.ID, o.EscalationLevel)) == 0 {
+		return fmt.Errorf("escalation policy of incident `%v` has no level %v: %w", o.ID, o.EscalationLevel, pagerduty.APIError{StatusCode: http.StatusBadRequest})
+	}
+
+	return nil
+}
+
+func (c *Client) priority(id string) *pagerduty.Priority {
+	for i := range c.priorities {
+		if c.priorities[i].ID == id {
+			return &c.priorities[i]
+		}
+	}
+	return nil
+}
+
+// oncallsAt returns the on-call entries at the given level of an escalation policy
+func (c *Client) oncallsAt(policyID string, level uint) []pagerduty.OnCall {
+	var oncalls []pagerduty.OnCall
+	for _, o := range c.oncalls {
+		if o.EscalationPolicy.ID == policyID && o.EscalationLevel == level {
+			oncalls = append(oncalls, o)
+		}
+	}
+	return oncalls
+}
+
+func (c *Client) setStatus(incident *pagerduty.Incident, status string, by *pagerduty.User, at string) {
+	switch status {
+	case "acknowledged":
+		incident.Acknowledgements = append(incident.Acknowledgements, pagerduty.Acknowledgement{At: at, Acknowledger: by.APIObject})
+		c.log(incident, "acknowledge", by.APIObject, at, "Acknowledged by "+by.Name)
+	case "triggered":
+		incident.Acknowledgements = nil
+		if incident.Status == "acknowledged" {
+			c.log(incident, "unacknowledge", by.APIObject, at, "Unacknowledged")
+		}
+	case "resolved":
+		c.log(incident, "resolve", by.APIObject, at, "Resolved by "+by.Name)
+		incident.ResolvedAt = at
+		for i := range c.alerts[incident.ID] {
+			c.alerts[incident.ID][i].Status = "resolved"
+		}
+		incident.AlertCounts.Resolved = incident.AlertCounts.All
+		incident.AlertCounts.Triggered = 0
+	}
+
+	incident.Status = status
+	incident.LastStatusChangeAt = at
+	incident.LastStatusChangeBy = by.APIObject
+	incident.UpdatedAt = at
+}
+
+// log records a log entry of the given type, e.g. acknowledge, on the incident
+func (c *Client) log(incident *pagerduty.Incident, kind string, agent pagerduty.APIObject, at, summary string) {
+	c.logEntries[incident.ID] = append(c.logEntries[incident.ID], pagerduty.LogEntry{
+		CommonLogEntryField: pagerduty.CommonLogEntryField{
+			APIObject: pagerduty.APIObject{ID: c.newID("L"), Type: kind + "_log_entry", Summary: summary},
+			CreatedAt: at,
+			Agent:     pagerduty.Agent(agent),
+			Channel:   pagerduty.Channel{Type: "api", Raw: map[string]interface{}{"type": "api"}},
+		},
+		Incident: pagerduty.Incident{APIObject: pagerduty.APIObject{ID: incident.ID, Type: "incident_reference", Summary: incident.Summary}},
+		Service:  incident.Service,
+	})
+}
+
+// assignees lists the names of the users an incident is assigned to
+func assignees(incident *pagerduty.Incident) string {
+	var names []string
+	for _, a := range incident.Assignments {
+		names = append(names, a.Assignee.Summary)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c *Client) matches(incident *pagerduty.Incident, opts pagerduty.ListIncidentsOptions) bool {
+	if len(opts.Statuses) > 0 && !contains(opts.Statuses, incident.Status) {
+		return false
+	}
+	if len(opts.Urgencies) > 0 && !contains(opts.Urgencies, incident.Urgency) {
+		return false
+	}
+	if len(opts.ServiceIDs) > 0 && !contains(opts.ServiceIDs, incident.Service.ID) {
+		return false
+	}
+	if opts.IncidentKey != "" && opts.IncidentKey != incident.IncidentKey {
+		return false
+	}
+
+	if len(opts.UserIDs) > 0 {
+		assigned := false
+		for _, a := range incident.Assignments {
+			if contains(opts.UserIDs, a.Assignee.ID) {
+				assigned = true
+			}
+		}
+		if !assigned {
+			return false
+		}
+	}
+
+	if len(opts.TeamIDs) > 0 {
+		inTeam := false
+		for _, t := range incident.Teams {
+			if contains(opts.TeamIDs, t.ID) {
+				inTeam = true
+			}
+		}
+		if !inTeam {
+			return false
+		}
+	}
+
+	created, err := time.Parse(time.RFC3339, incident.CreatedAt)
+	if err != nil {
+		return true
+	}
+	if since, err := time.Parse(time.RFC3339, opts.Since); err == nil && created.Before(since) {
+		return false
+	}
+	if until, err := time.Parse(time.RFC3339, opts.Until); err == nil && !created.Before(until) {
+		return false
+	}
+
+	return true
+}
+
+func (c *Client) currentUser() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.currentUserID
+}
+
+func (c *Client) incident(id string) *pagerduty.Incident {
+	for _, incident := range c.incidents {
+		if incident.ID == id {
+			return incident
+		}
+	}
+	return nil
+}
+
+func (c *Client) userByEmail(email string) *pagerduty.User {
+	for _, user := range c.users {
+		if user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
+
+func (c *Client) newID(prefix string) string {
+	c.nextID++
+	return fmt.Sprintf("%v%06d", prefix, c.nextID)
+}
+
+func (c *Client) timestamp() string {
+	return c.Now().UTC().Format(time.RFC3339)
+}
+
+// paginate returns the page of items PagerDuty would return for limit and offset
+func paginate[T any](items []T, limit, offset uint) ([]T, pagerduty.APIListObject) {
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	list := pagerduty.APIListObject{Limit: limit, Offset: offset, Total: uint(len(items))}
+
+	if offset >= uint(len(items)) {
+		return []T{}, list
+	}
+
+	end := offset + limit
+	if end >= uint(len(items)) {
+		end = uint(len(items))
+	} else {
+		list.More = true
+	}
+
+	return append([]T{}, items[offset:end]...), list
+}
+
+// notFound returns the error the PagerDuty client returns for unknown objects
+func notFound(kind, id string) error {
+	return fmt.Errorf("%v `%v`: %w", kind, id, pagerduty.APIError{StatusCode: http.StatusNotFound})
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,282 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newClient returns a client with two users and n triggered incidents assigned to the first one
+func newClient(n int) *Client {
+	c := New()
+	c.Now = func() time.Time { return now }
+	c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER1"}, Name: "Jane Doe", Email: "jane@example.com"})
+	c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER2"}, Name: "John Roe", Email: "john@example.com"})
+
+	for i := 1; i <= n; i++ {
+		c.AddIncident(pagerduty.Incident{
+			APIObject:   pagerduty.APIObject{ID: fmt.Sprintf("PINC%03d", i)},
+			Title:       fmt.Sprintf("Incident %v", i),
+			Status:      "triggered",
+			Urgency:     "high",
+			CreatedAt:   now.Add(-time.Duration(i) * time.Minute).Format(time.RFC3339),
+			Assignments: []pagerduty.Assignment{{Assignee: pagerduty.APIObject{ID: "PUSER1", Type: "user_reference"}}},
+		})
+	}
+	return c
+}
+
+func TestListIncidentsPagination(t *testing.T) {
+	c := newClient(5)
+	ctx := context.Background()
+
+	tests := []struct {
+		limit, offset uint
+		wantIDs       []string
+		wantMore      bool
+		wantLimit     uint
+	}{
+		{limit: 2, offset: 0, wantIDs: []string{"PINC001", "PINC002"}, wantMore: true, wantLimit: 2},
+		{limit: 2, offset: 2, wantIDs: []string{"PINC003", "PINC004"}, wantMore: true, wantLimit: 2},
+		{limit: 2, offset: 4, wantIDs: []string{"PINC005"}, wantMore: false, wantLimit: 2},
+		{limit: 5, offset: 0, wantIDs: []string{"PINC001", "PINC002", "PINC003", "PINC004", "PINC005"}, wantMore: false, wantLimit: 5},
+		{limit: 2, offset: 10, wantIDs: nil, wantMore: false, wantLimit: 2},
+		// PagerDuty pages by 25 when no limit is given
+		{limit: 0, offset: 0, wantIDs: []string{"PINC001", "PINC002", "PINC003", "PINC004", "PINC005"}, wantMore: false, wantLimit: defaultLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit %v offset %v", tt.limit, tt.offset), func(t *testing.T) {
+			response, err := c.ListIncidentsWithContext(ctx, pagerduty.ListIncidentsOptions{Limit: tt.limit, Offset: tt.offset})
+			if err != nil {
+				t.Fatalf("ListIncidents() error = %v", err)
+			}
+
+			var ids []string
+			for _, i := range response.Incidents {
+				ids = append(ids, i.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ListIncidents() = %v, want %v", ids, tt.wantIDs)
+			}
+			if response.More != tt.wantMore {
+				t.Errorf("More = %v, want %v", response.More, tt.wantMore)
+			}
+			if response.Total != 5 {
+				t.Errorf("Total = %v, want 5", response.Total)
+			}
+			if response.Limit != tt.wantLimit {
+				t.Errorf("Limit = %v, want %v", response.Limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestManageIncidents(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("acknowledge", func(t *testing.T) {
+		c := newClient(1)
+		response, err := c.ManageIncidentsWithContext(ctx, "john@example.com", []pagerduty.ManageIncidentsOptions{{ID: "PINC001", Status: "acknowledged"}})
+		if err != nil {
+			t.Fatalf("ManageIncidents() error = %v", err)
+		}
+
+		incident, _ := c.GetIncidentWithContext(ctx, "PINC001")
+		if incident.Status != "acknowledged" || incident.LastStatusChangeAt != now.Format(time.RFC3339) {
+			t.Errorf("incident = %v changed at %v, want acknowledged at %v", incident.Status, incident.LastStatusChangeAt, now)
+		}
+		if len(incident.Acknowledgements) != 1 || incident.Acknowledgements[0].Acknowledger.ID != "PUSER2" {
+			t.Errorf("acknowledgements = %+v, want PUSER2", incident.Acknowledgements)
+		}
+		if len(response.Incidents) != 1 || response.Incidents[0].Status != "acknowledged" {
+			t.Errorf("response = %+v, want the acknowledged incident", response.Incidents)
+		}
+	})
+
+	t.Run("reassign triggers the incident again", func(t *testing.T) {
+		c := newClient(1)
+		if _, err := c.ManageIncidentsWithContext(ctx, "jane@example.com", []pagerduty.ManageIncidentsOptions{{ID: "PINC001", Status: "acknowledged"}}); err != nil {
+			t.Fatalf("ManageIncidents() error = %v", err)
+		}
+
+		_, err := c.ManageIncidentsWithContext(ctx, "jane@example.com", []pagerduty.ManageIncidentsOptions{{
+			ID:          "PINC001",
+			Assignments: []pagerduty.Assignee{{Assignee: pagerduty.APIObject{ID: "PUSER2", Type: "user_reference"}}},
+		}})
+		if err != nil {
+			t.Fatalf("ManageIncidents() error = %v", err)
+		}
+
+		incident, _ := c.GetIncidentWithContext(ctx, "PINC001")
+		if len(incident.Assignments) != 1 || incident.Assignments[0].Assignee.ID != "PUSER2" || incident.Assignments[0].Assignee.Summary != "John Roe" {
+			t.Errorf("assignments = %+v, want John Roe", incident.Assignments)
+		}
+		if incident.Status != "triggered" || len(incident.Acknowledgements) != 0 {
+			t.Errorf("incident = %v with %v acknowledgement(s), want triggered without any", incident.Status, len(incident.Acknowledgements))
+		}
+	})
+
+	t.Run("resolve resolves the alerts", func(t *testing.T) {
+		c := newClient(0)
+		c.AddIncident(pagerduty.Incident{APIObject: pagerduty.APIObject{ID: "PINC001"}, Status: "triggered"},
+			pagerduty.IncidentAlert{APIObject: pagerduty.APIObject{ID: "PALT001"}})
+
+		if _, err := c.ManageIncidentsWithContext(ctx, "jane@example.com", []pagerduty.ManageIncidentsOptions{{ID: "PINC001", Status: "resolved"}}); err != nil {
+			t.Fatalf("ManageIncidents() error = %v", err)
+		}
+
+		incident, _ := c.GetIncidentWithContext(ctx, "PINC001")
+		if incident.Status != "resolved" || incident.ResolvedAt == "" {
+			t.Errorf("incident = %v resolved at %q, want resolved", incident.Status, incident.ResolvedAt)
+		}
+		if incident.AlertCounts.Triggered != 0 || incident.AlertCounts.Resolved != 1 {
+			t.Errorf("alert counts = %+v, want 1 resolved", incident.AlertCounts)
+		}
+		alerts, _ := c.ListIncidentAlertsWithContext(ctx, "PINC001", pagerduty.ListIncidentAlertsOptions{})
+		if alerts.Alerts[0].Status != "resolved" {
+			t.Errorf("alert status = %v, want resolved", alerts.Alerts[0].Status)
+		}
+	})
+}
+
+func TestManageIncidentsRejections(t *testing.T) {
+	ctx := context.Background()
+
+	var tooMany []pagerduty.ManageIncidentsOptions
+	for i := 1; i <= maxManageIncidents+1; i++ {
+		tooMany = append(tooMany, pagerduty.ManageIncidentsOptions{ID: fmt.Sprintf("PINC%03d", i), Status: "acknowledged"})
+	}
+
+	tests := []struct {
+		name       string
+		email      string
+		opts       []pagerduty.ManageIncidentsOptions
+		wantStatus int
+	}{
+		{
+			name:       "more than 250 incidents",
+			email:      "jane@example.com",
+			opts:       tooMany,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "unknown incident among known ones",
+			email: "jane@example.com",
+			opts: []pagerduty.ManageIncidentsOptions{
+				{ID: "PINC001", Status: "acknowledged"},
+				{ID: "PNOPE", Status: "acknowledged"},
+				{ID: "PINC002", Status: "acknowledged"},
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:  "unknown priority",
+			email: "jane@example.com",
+			opts: []pagerduty.ManageIncidentsOptions{
+				{ID: "PINC001", Status: "acknowledged"},
+				{ID: "PINC002", Priority: &pagerduty.APIReference{ID: "PNOPE", Type: "priority_reference"}},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown user",
+			email:      "nobody@example.com",
+			opts:       []pagerduty.ManageIncidentsOptions{{ID: "PINC001", Status: "acknowledged"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClient(maxManageIncidents + 1)
+
+			_, err := c.ManageIncidentsWithContext(ctx, tt.email, tt.opts)
+
+			var apiErr pagerduty.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+				t.Fatalf("ManageIncidents() error = %v, want an APIError with status %v", err, tt.wantStatus)
+			}
+
+			// PagerDuty applies all of a request or none of it
+			response, err := c.ListIncidentsWithContext(ctx, pagerduty.ListIncidentsOptions{Statuses: []string{"acknowledged"}})
+			if err != nil {
+				t.Fatalf("ListIncidents() error = %v", err)
+			}
+			if len(response.Incidents) != 0 {
+				t.Errorf("%v incident(s) acknowledged by a rejected request, want none", len(response.Incidents))
+			}
+		})
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	// A second fixture adds a note to the incident of the first one
+	extra := writeFile(t, "extra.json", `{
+		"users": [{"id": "PUSER03", "name": "Sam Poe", "email": "sam@example.com"}],
+		"incidents": [{
+			"incident": {"id": "PINC002", "title": "Second", "status": "triggered", "created_at": "2024-05-01T11:00:00Z"},
+			"notes": [{"id": "PNOTE2", "content": "Seeded note", "created_at": "2024-05-01T11:05:00Z", "user": {"id": "PUSER03"}}]
+		}]
+	}`)
+
+	c, err := LoadFixtures("testdata/basic.json", extra)
+	if err != nil {
+		t.Fatalf("LoadFixtures() error = %v", err)
+	}
+
+	user, err := c.GetCurrentUserWithContext(ctx, pagerduty.GetCurrentUserOptions{})
+	if err != nil || user.ID != "PUSER01" {
+		t.Errorf("GetCurrentUser() = %v, %v, want PUSER01 from the fixture", user, err)
+	}
+
+	// Users added without a type or summary get them, like the API returns
+	sam, err := c.GetUserWithContext(ctx, "PUSER03", pagerduty.GetUserOptions{})
+	if err != nil || sam.Type != "user" || sam.Summary != "Sam Poe" {
+		t.Errorf("GetUser() = %+v, %v, want type user and summary Sam Poe", sam, err)
+	}
+
+	members, err := c.ListMembersWithContext(ctx, "PTEAM01", pagerduty.ListTeamMembersOptions{})
+	if err != nil || len(members.Members) != 2 {
+		t.Errorf("ListMembers() = %+v, %v, want the 2 members of the fixture", members, err)
+	}
+
+	incidents, err := c.ListIncidentsWithContext(ctx, pagerduty.ListIncidentsOptions{})
+	if err != nil || len(incidents.Incidents) != 2 {
+		t.Fatalf("ListIncidents() = %+v, %v, want the incidents of both fixtures", incidents, err)
+	}
+	if incidents.Incidents[0].AlertCounts.All == 0 {
+		t.Errorf("alert counts of %v = %+v, want them counted from the seeded alerts", incidents.Incidents[0].ID, incidents.Incidents[0].AlertCounts)
+	}
+
+	notes, err := c.ListIncidentNotesWithContext(ctx, "PINC002")
+	if err != nil || len(notes) != 1 || notes[0].Content != "Seeded note" {
+		t.Errorf("ListIncidentNotes() = %+v, %v, want the seeded note", notes, err)
+	}
+
+	for _, path := range []string{"testdata/missing.json", writeFile(t, "bad.json", "{")} {
+		if _, err := LoadFixtures(path); err == nil {
+			t.Errorf("LoadFixtures(%v) error = nil, want an error", path)
+		}
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
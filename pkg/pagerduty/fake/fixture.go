@@ -0,0 +1,91 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// Fixture is the JSON file format used to seed a Client. Objects use the PagerDuty REST API
+// representation, so fixtures can be built from real API responses.
+type Fixture struct {
+	CurrentUser string               `json:"current_user"`
+	Users       []pagerduty.User     `json:"users"`
+	Teams       []FixtureTeam        `json:"teams"`
+	Services    []pagerduty.Service  `json:"services"`
+	Priorities  []pagerduty.Priority `json:"priorities"`
+	OnCalls     []pagerduty.OnCall   `json:"oncalls"`
+	Incidents   []FixtureIncident    `json:"incidents"`
+}
+
+type FixtureTeam struct {
+	Team    pagerduty.Team `json:"team"`
+	Members []string       `json:"members"`
+}
+
+type FixtureIncident struct {
+	Incident pagerduty.Incident        `json:"incident"`
+	Alerts   []pagerduty.IncidentAlert `json:"alerts"`
+	Notes    []pagerduty.IncidentNote  `json:"notes"`
+	// LogEntries are added to those recorded for the incident being triggered and acknowledged
+	LogEntries []pagerduty.LogEntry `json:"log_entries"`
+}
+
+// LoadFixtures returns a client seeded with the contents of every fixture file, in order.
+func LoadFixtures(paths ...string) (*Client, error) {
+	c := New()
+
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("fake.LoadFixtures(): failed to read `%v`: %v", path, err)
+		}
+
+		var f Fixture
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("fake.LoadFixtures(): failed to parse `%v`: %v", path, err)
+		}
+
+		c.Seed(f)
+	}
+
+	return c, nil
+}
+
+// Seed adds the contents of a fixture to the client.
+func (c *Client) Seed(f Fixture) {
+	for _, u := range f.Users {
+		c.AddUser(u)
+	}
+	if f.CurrentUser != "" {
+		c.SetCurrentUser(f.CurrentUser)
+	}
+
+	for _, t := range f.Teams {
+		c.AddTeam(t.Team, t.Members...)
+	}
+
+	for _, s := range f.Services {
+		c.AddService(s)
+	}
+
+	for _, p := range f.Priorities {
+		c.AddPriority(p)
+	}
+
+	for _, o := range f.OnCalls {
+		c.AddOnCall(o)
+	}
+
+	for _, i := range f.Incidents {
+		c.AddIncident(i.Incident, i.Alerts...)
+		for _, n := range i.Notes {
+			c.AddNote(i.Incident.ID, n)
+		}
+		for _, e := range i.LogEntries {
+			c.AddLogEntry(i.Incident.ID, e)
+		}
+	}
+}
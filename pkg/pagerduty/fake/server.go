@@ -0,0 +1,311 @@
+package fake
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// NewServer starts a local HTTP server emulating the parts of the PagerDuty REST API used by the
+// pd package, backed by c. Point a pagerduty.Client at it with pagerduty.WithAPIEndpoint(server.URL).
+// The caller must Close the server.
+func NewServer(c *Client) *httptest.Server {
+	return httptest.NewServer(c.Handler())
+}
+
+// Handler serves the PagerDuty REST API endpoints for incidents, alerts, notes, teams, users,
+// services, priorities and on-calls from the client's in-memory data.
+func (c *Client) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /users", c.handleListUsers)
+	mux.HandleFunc("GET /users/me", c.handleGetCurrentUser)
+	mux.HandleFunc("GET /users/{id}", c.handleGetUser)
+	mux.HandleFunc("GET /teams", c.handleListTeams)
+	mux.HandleFunc("GET /teams/{id}", c.handleGetTeam)
+	mux.HandleFunc("GET /teams/{id}/members", c.handleListMembers)
+	mux.HandleFunc("GET /services", c.handleListServices)
+	mux.HandleFunc("GET /services/{id}", c.handleGetService)
+	mux.HandleFunc("GET /priorities", c.handleListPriorities)
+	mux.HandleFunc("GET /oncalls", c.handleListOnCalls)
+	mux.HandleFunc("GET /incidents", c.handleListIncidents)
+	mux.HandleFunc("PUT /incidents", c.handleManageIncidents)
+	mux.HandleFunc("GET /incidents/{id}", c.handleGetIncident)
+	mux.HandleFunc("PUT /incidents/{id}", c.handleUpdateIncident)
+	mux.HandleFunc("PUT /incidents/{id}/merge", c.handleMergeIncidents)
+	mux.HandleFunc("POST /incidents/{id}/snooze", c.handleSnoozeIncident)
+	mux.HandleFunc("GET /incidents/{id}/alerts", c.handleListAlerts)
+	mux.HandleFunc("GET /incidents/{id}/log_entries", c.handleListLogEntries)
+	mux.HandleFunc("GET /incidents/{id}/notes", c.handleListNotes)
+	mux.HandleFunc("POST /incidents/{id}/notes", c.handleCreateNote)
+
+	return requireToken(mux)
+}
+
+// requireToken rejects requests without an API token, like PagerDuty does
+func requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			writeError(w, http.StatusUnauthorized, "Unauthorized", 2006)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *Client) handleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, err := c.GetCurrentUserWithContext(r.Context(), pagerduty.GetCurrentUserOptions{})
+	respond(w, map[string]interface{}{"user": user}, err)
+}
+
+func (c *Client) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := c.GetUserWithContext(r.Context(), r.PathValue("id"), pagerduty.GetUserOptions{})
+	respond(w, map[string]interface{}{"user": user}, err)
+}
+
+func (c *Client) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	var users []pagerduty.User
+	for _, u := range c.users {
+		users = append(users, *u)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	limit, offset := pagination(r)
+	page, list := paginate(users, limit, offset)
+	respond(w, pagerduty.ListUsersResponse{APIListObject: list, Users: page}, nil)
+}
+
+func (c *Client) handleGetTeam(w http.ResponseWriter, r *http.Request) {
+	team, err := c.GetTeamWithContext(r.Context(), r.PathValue("id"))
+	respond(w, map[string]interface{}{"team": team}, err)
+}
+
+func (c *Client) handleListTeams(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	var teams []pagerduty.Team
+	for _, t := range c.teams {
+		teams = append(teams, *t)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(teams, func(i, j int) bool { return teams[i].ID < teams[j].ID })
+	limit, offset := pagination(r)
+	page, list := paginate(teams, limit, offset)
+	respond(w, pagerduty.ListTeamResponse{APIListObject: list, Teams: page}, nil)
+}
+
+func (c *Client) handleListMembers(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	response, err := c.ListMembersWithContext(r.Context(), r.PathValue("id"), pagerduty.ListTeamMembersOptions{Limit: limit, Offset: offset})
+	respond(w, response, err)
+}
+
+func (c *Client) handleGetService(w http.ResponseWriter, r *http.Request) {
+	service, err := c.GetServiceWithContext(r.Context(), r.PathValue("id"), &pagerduty.GetServiceOptions{})
+	respond(w, map[string]interface{}{"service": service}, err)
+}
+
+func (c *Client) handleListServices(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	var services []pagerduty.Service
+	for _, s := range c.services {
+		services = append(services, *s)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(services, func(i, j int) bool { return services[i].ID < services[j].ID })
+	limit, offset := pagination(r)
+	page, list := paginate(services, limit, offset)
+	respond(w, pagerduty.ListServiceResponse{APIListObject: list, Services: page}, nil)
+}
+
+func (c *Client) handleListPriorities(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	response, err := c.ListPrioritiesWithContext(r.Context(), pagerduty.ListPrioritiesOptions{Limit: limit, Offset: offset})
+	respond(w, response, err)
+}
+
+func (c *Client) handleListOnCalls(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	response, err := c.ListOnCallsWithContext(r.Context(), pagerduty.ListOnCallOptions{
+		Limit:               limit,
+		Offset:              offset,
+		UserIDs:             r.URL.Query()["user_ids[]"],
+		EscalationPolicyIDs: r.URL.Query()["escalation_policy_ids[]"],
+	})
+	respond(w, response, err)
+}
+
+func (c *Client) handleListIncidents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, offset := pagination(r)
+
+	response, err := c.ListIncidentsWithContext(r.Context(), pagerduty.ListIncidentsOptions{
+		Limit:       limit,
+		Offset:      offset,
+		Since:       q.Get("since"),
+		Until:       q.Get("until"),
+		Statuses:    q["statuses[]"],
+		IncidentKey: q.Get("incident_key"),
+		ServiceIDs:  q["service_ids[]"],
+		TeamIDs:     q["team_ids[]"],
+		UserIDs:     q["user_ids[]"],
+		Urgencies:   q["urgencies[]"],
+	})
+	respond(w, response, err)
+}
+
+func (c *Client) handleManageIncidents(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Incidents []pagerduty.ManageIncidentsOptions `json:"incidents"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid Input Provided", 2001)
+		return
+	}
+
+	response, err := c.ManageIncidentsWithContext(r.Context(), r.Header.Get("From"), body.Incidents)
+	respond(w, response, err)
+}
+
+func (c *Client) handleGetIncident(w http.ResponseWriter, r *http.Request) {
+	incident, err := c.GetIncidentWithContext(r.Context(), r.PathValue("id"))
+	respond(w, map[string]interface{}{"incident": incident}, err)
+}
+
+// handleUpdateIncident only supports changing the urgency
+func (c *Client) handleUpdateIncident(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Incident struct {
+			Urgency string `json:"urgency"`
+		} `json:"incident"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid Input Provided", 2001)
+		return
+	}
+
+	incident, err := c.UpdateIncidentUrgencyWithContext(r.Context(), r.Header.Get("From"), r.PathValue("id"), body.Incident.Urgency)
+	respond(w, map[string]interface{}{"incident": incident}, err)
+}
+
+func (c *Client) handleMergeIncidents(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		SourceIncidents []pagerduty.MergeIncidentsOptions `json:"source_incidents"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid Input Provided", 2001)
+		return
+	}
+
+	incident, err := c.MergeIncidentsWithContext(r.Context(), r.Header.Get("From"), r.PathValue("id"), body.SourceIncidents)
+	respond(w, map[string]interface{}{"incident": incident}, err)
+}
+
+func (c *Client) handleSnoozeIncident(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Duration uint `json:"duration"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid Input Provided", 2001)
+		return
+	}
+
+	incident, err := c.SnoozeIncidentWithContext(r.Context(), r.Header.Get("From"), r.PathValue("id"), body.Duration)
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"incident": incident})
+		return
+	}
+	respond(w, nil, err)
+}
+
+func (c *Client) handleListAlerts(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	response, err := c.ListIncidentAlertsWithContext(r.Context(), r.PathValue("id"), pagerduty.ListIncidentAlertsOptions{
+		Limit:    limit,
+		Offset:   offset,
+		Statuses: r.URL.Query()["statuses[]"],
+	})
+	respond(w, response, err)
+}
+
+func (c *Client) handleListLogEntries(w http.ResponseWriter, r *http.Request) {
+	limit, offset := pagination(r)
+	response, err := c.ListIncidentLogEntriesWithContext(r.Context(), r.PathValue("id"), pagerduty.ListIncidentLogEntriesOptions{
+		Limit:  limit,
+		Offset: offset,
+		Since:  r.URL.Query().Get("since"),
+		Until:  r.URL.Query().Get("until"),
+	})
+	respond(w, response, err)
+}
+
+func (c *Client) handleListNotes(w http.ResponseWriter, r *http.Request) {
+	notes, err := c.ListIncidentNotesWithContext(r.Context(), r.PathValue("id"))
+	respond(w, map[string]interface{}{"notes": notes}, err)
+}
+
+func (c *Client) handleCreateNote(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Note pagerduty.IncidentNote `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid Input Provided", 2001)
+		return
+	}
+
+	note, err := c.CreateIncidentNoteWithContext(r.Context(), r.PathValue("id"), body.Note)
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"note": note})
+		return
+	}
+	respond(w, nil, err)
+}
+
+// pagination reads the limit and offset query parameters
+func pagination(r *http.Request) (limit, offset uint) {
+	l, _ := strconv.ParseUint(r.URL.Query().Get("limit"), 10, 32)
+	o, _ := strconv.ParseUint(r.URL.Query().Get("offset"), 10, 32)
+	return uint(l), uint(o)
+}
+
+// respond writes v as JSON, or the PagerDuty error object matching err
+func respond(w http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		var apiErr pagerduty.APIError
+		if errors.As(err, &apiErr) {
+			switch apiErr.StatusCode {
+			case http.StatusNotFound:
+				writeError(w, http.StatusNotFound, "Not Found", 2100)
+			default:
+				writeError(w, apiErr.StatusCode, err.Error(), 2001)
+			}
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, err.Error(), 2000)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "code": code},
+	})
+}
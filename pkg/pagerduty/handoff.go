@@ -0,0 +1,379 @@
+package pd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// Defaults of HandoffOptions
+const (
+	DefaultHandoffTopClusters = 5
+
+	// handoffLookback is how long before the shift incidents resolved during it may have been created
+	handoffLookback = 7 * 24 * time.Hour
+)
+
+// HandoffOptions selects the shift a handoff report covers.
+type HandoffOptions struct {
+	Since time.Time
+	Until time.Time
+	// UserIDs are the users whose open incidents are handed off, the team members if empty, or the
+	// incidents of the teams if they have no members either
+	UserIDs []string
+	// TopClusters is how many of the noisiest clusters are listed
+	TopClusters int
+	// Workers bounds the number of incidents whose alerts and notes are fetched concurrently
+	Workers int
+}
+
+// HandoffIncident is an incident listed in a handoff report along with the clusters its alerts are on.
+type HandoffIncident struct {
+	IncidentSummary `yaml:",inline"`
+	Clusters        []string `json:"clusters" yaml:"clusters"`
+}
+
+// Handoff is the summary of an on-call shift handed over to the next on-call.
+type Handoff struct {
+	Since string `json:"since" yaml:"since"`
+	Until string `json:"until" yaml:"until"`
+	// Open are the incidents of the team still triggered or acknowledged
+	Open []HandoffIncident `json:"open" yaml:"open"`
+	// Resolved are the incidents resolved during the shift
+	Resolved []HandoffIncident `json:"resolved" yaml:"resolved"`
+	// Silenced are the open incidents assigned to the silent user
+	Silenced []HandoffIncident `json:"silenced" yaml:"silenced"`
+	// NoisyClusters are the clusters with the most alerts among the incidents triggered during the shift
+	NoisyClusters []ClusterGroup `json:"noisy_clusters" yaml:"noisy_clusters"`
+	// Triggered is the number of incidents triggered during the shift
+	Triggered int `json:"triggered" yaml:"triggered"`
+	// Notes are the notes written during the shift on any of the incidents above
+	Notes []NoteSummary `json:"notes" yaml:"notes"`
+	// Errors are the parts of incidents that could not be fetched
+	Errors []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// GetHandoff calls GetHandoffWithContext with a background context.
+func GetHandoff(c *Config, opts HandoffOptions) (*Handoff, error) {
+	return GetHandoffWithContext(context.Background(), c, opts)
+}
+
+// GetHandoffWithContext gathers the handoff report of the shift between opts.Since and opts.Until. As
+// PagerDuty unassigns incidents when they are resolved, the resolved and triggered incidents are
+// those of the configured teams rather than of their members.
+func GetHandoffWithContext(ctx context.Context, c *Config, opts HandoffOptions) (*Handoff, error) {
+	if !opts.Since.Before(opts.Until) {
+		return nil, fmt.Errorf("pd.GetHandoff(): the shift must start before it ends, got %v to %v", opts.Since, opts.Until)
+	}
+	if len(opts.UserIDs) == 0 {
+		opts.UserIDs = c.TeamsMemberIDs
+	}
+	// Listing incidents by neither users nor teams would hand off those of the whole account
+	if len(opts.UserIDs) == 0 && len(c.Teams) == 0 {
+		return nil, fmt.Errorf("pd.GetHandoff(): no team members or teams to hand off the incidents of")
+	}
+	if opts.TopClusters <= 0 {
+		opts.TopClusters = DefaultHandoffTopClusters
+	}
+
+	since, until := opts.Since.UTC().Format(time.RFC3339), opts.Until.UTC().Format(time.RFC3339)
+
+	// Open incidents are listed whenever they were triggered, the others by the window they were
+	// triggered in. Without users, those of the teams are listed.
+	list := func(statuses []string, users []string, since string) ([]pagerduty.Incident, error) {
+		o := NewListIncidentOptsFromDefaults()
+		o.Statuses = statuses
+		if len(users) > 0 {
+			o.UserIDs = users
+		} else {
+			o.TeamIDs = teamIDs(c)
+		}
+		if since != "" {
+			o.Since, o.Until = since, until
+		} else {
+			o.DateRange = "all"
+		}
+		return GetIncidentsWithContext(ctx, c.Client, o)
+	}
+
+	// Without teams, the incidents of the team members are the best guess at those of the team
+	team := func(statuses []string, since string) ([]pagerduty.Incident, error) {
+		if len(c.Teams) == 0 {
+			return list(statuses, opts.UserIDs, since)
+		}
+		o := NewListIncidentOptsFromDefaults()
+		o.Statuses = statuses
+		o.TeamIDs = teamIDs(c)
+		o.Since, o.Until = since, until
+		return GetIncidentsWithContext(ctx, c.Client, o)
+	}
+
+	open, err := list([]string{"triggered", "acknowledged"}, opts.UserIDs, "")
+	if err != nil {
+		return nil, fmt.Errorf("pd.GetHandoff(): %v", err)
+	}
+
+	var silenced []pagerduty.Incident
+	if c.SilentUser != nil {
+		silenced, err = list([]string{"triggered", "acknowledged"}, []string{c.SilentUser.ID}, "")
+		if err != nil {
+			return nil, fmt.Errorf("pd.GetHandoff(): %v", err)
+		}
+	}
+
+	resolved, err := team([]string{"resolved"}, opts.Since.Add(-handoffLookback).UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("pd.GetHandoff(): %v", err)
+	}
+	resolved = slices.DeleteFunc(resolved, func(i pagerduty.Incident) bool {
+		at := i.ResolvedAt
+		if at == "" {
+			at = i.LastStatusChangeAt
+		}
+		return before(at, since) || !before(at, until)
+	})
+
+	triggered, err := team([]string{"triggered", "acknowledged", "resolved"}, since)
+	if err != nil {
+		return nil, fmt.Errorf("pd.GetHandoff(): %v", err)
+	}
+
+	// Each incident is only enriched once, whichever lists it appears in
+	var all []pagerduty.Incident
+	seen := map[string]bool{}
+	for _, list := range [][]pagerduty.Incident{open, silenced, resolved, triggered} {
+		for _, i := range list {
+			if !seen[i.ID] {
+				seen[i.ID] = true
+				all = append(all, i)
+			}
+		}
+	}
+
+	enriched, err := EnrichIncidentsWithContext(ctx, c.Client, all, EnrichOptions{
+		Workers:          opts.Workers,
+		WithoutAssignees: true,
+		WithoutService:   true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.GetHandoff(): %v", err)
+	}
+
+	byID := map[string]EnrichedIncident{}
+	for _, e := range enriched {
+		byID[e.Incident.ID] = e
+	}
+
+	h := &Handoff{
+		Since:         since,
+		Until:         until,
+		Open:          handoffIncidents(open, byID),
+		Resolved:      handoffIncidents(resolved, byID),
+		Silenced:      handoffIncidents(silenced, byID),
+		NoisyClusters: []ClusterGroup{},
+		Triggered:     len(triggered),
+		Notes:         []NoteSummary{},
+	}
+
+	var shift []EnrichedIncident
+	for _, i := range triggered {
+		shift = append(shift, byID[i.ID])
+	}
+	for _, g := range GroupByCluster(shift) {
+		if g.ClusterID != UnknownCluster || g.ClusterName != UnknownCluster {
+			h.NoisyClusters = append(h.NoisyClusters, g)
+		}
+	}
+	sort.SliceStable(h.NoisyClusters, func(i, j int) bool {
+		if h.NoisyClusters[i].Alerts != h.NoisyClusters[j].Alerts {
+			return h.NoisyClusters[i].Alerts > h.NoisyClusters[j].Alerts
+		}
+		return len(h.NoisyClusters[i].IncidentIDs) > len(h.NoisyClusters[j].IncidentIDs)
+	})
+	h.NoisyClusters = h.NoisyClusters[:min(opts.TopClusters, len(h.NoisyClusters))]
+
+	for _, e := range enriched {
+		for _, n := range NewNoteSummaries(e.Incident.ID, e.Notes) {
+			if !before(n.CreatedAt, since) && before(n.CreatedAt, until) {
+				h.Notes = append(h.Notes, n)
+			}
+		}
+		for _, msg := range e.Errors {
+			h.Errors = append(h.Errors, fmt.Sprintf("incident %v: %v", e.Incident.ID, msg))
+		}
+	}
+	sort.SliceStable(h.Notes, func(i, j int) bool { return before(h.Notes[i].CreatedAt, h.Notes[j].CreatedAt) })
+
+	return h, nil
+}
+
+// Markdown renders the handoff report as a Markdown document.
+func (h *Handoff) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Shift handoff\n\n")
+	fmt.Fprintf(&b, "%v to %v: %v incident(s) triggered, %v resolved, %v still open, %v silenced.\n",
+		h.Since, h.Until, h.Triggered, len(h.Resolved), len(h.Open), len(h.Silenced))
+
+	incidents := func(title string, incidents []HandoffIncident) {
+		fmt.Fprintf(&b, "\n## %v\n\n", title)
+		if len(incidents) == 0 {
+			b.WriteString("None.\n")
+			return
+		}
+		b.WriteString("| Incident | Status | Urgency | Severity | Clusters | Assignees | Title |\n")
+		b.WriteString("|---|---|---|---|---|---|---|\n")
+		for _, i := range incidents {
+			id := i.ID
+			if i.WebURL != "" {
+				id = fmt.Sprintf("[%v](%v)", i.ID, i.WebURL)
+			}
+			fmt.Fprintf(&b, "| %v | %v | %v | %v | %v | %v | %v |\n", id, i.Status, i.Urgency, i.Severity,
+				markdownCell(strings.Join(i.Clusters, ", ")), markdownCell(strings.Join(i.Assignees, ", ")), markdownCell(i.Title))
+		}
+	}
+
+	incidents("Still open", h.Open)
+	incidents("Resolved during the shift", h.Resolved)
+	incidents("Silenced", h.Silenced)
+
+	b.WriteString("\n## Noisiest clusters\n\n")
+	if len(h.NoisyClusters) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("| Cluster | Cluster ID | Incidents | Alerts | Severity |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, g := range h.NoisyClusters {
+			fmt.Fprintf(&b, "| %v | %v | %v | %v | %v |\n", markdownCell(g.ClusterName), g.ClusterID, len(g.IncidentIDs), g.Alerts, g.Severity)
+		}
+	}
+
+	b.WriteString("\n## Notes\n\n")
+	if len(h.Notes) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, n := range h.Notes {
+		fmt.Fprintf(&b, "- %v, %v on %v: %v\n", n.CreatedAt, markdownEscape(n.Author), n.IncidentID, markdownCell(n.Content))
+	}
+
+	return b.String()
+}
+
+// Text renders the handoff report as plain text, e.g. for a chat message.
+func (h *Handoff) Text() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Shift handoff %v to %v\n", h.Since, h.Until)
+	fmt.Fprintf(&b, "%v incident(s) triggered, %v resolved, %v still open, %v silenced\n",
+		h.Triggered, len(h.Resolved), len(h.Open), len(h.Silenced))
+
+	incidents := func(title string, incidents []HandoffIncident) {
+		fmt.Fprintf(&b, "\n%v (%v)\n", title, len(incidents))
+		for _, i := range incidents {
+			fmt.Fprintf(&b, "  %v  %-12v %-4v  %v", i.ID, i.Status, i.Urgency, i.Title)
+			if len(i.Clusters) > 0 {
+				fmt.Fprintf(&b, " [%v]", strings.Join(i.Clusters, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	incidents("Still open", h.Open)
+	incidents("Resolved during the shift", h.Resolved)
+	incidents("Silenced", h.Silenced)
+
+	fmt.Fprintf(&b, "\nNoisiest clusters (%v)\n", len(h.NoisyClusters))
+	for _, g := range h.NoisyClusters {
+		fmt.Fprintf(&b, "  %v  %v alert(s) in %v incident(s)\n", g.ClusterName, g.Alerts, len(g.IncidentIDs))
+	}
+
+	fmt.Fprintf(&b, "\nNotes (%v)\n", len(h.Notes))
+	for _, n := range h.Notes {
+		fmt.Fprintf(&b, "  %v %v on %v: %v\n", n.CreatedAt, n.Author, n.IncidentID, strings.ReplaceAll(strings.TrimSpace(n.Content), "\n", " "))
+	}
+
+	return b.String()
+}
+
+var handoffHTML = template.Must(template.New("handoff").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Shift handoff {{.Since}} to {{.Until}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Shift handoff</h1>
+<p>{{.Since}} to {{.Until}}: {{.Triggered}} incident(s) triggered, {{len .Resolved}} resolved, {{len .Open}} still open, {{len .Silenced}} silenced.</p>
+{{define "incidents"}}{{if .}}<table>
+<tr><th>Incident</th><th>Status</th><th>Urgency</th><th>Severity</th><th>Clusters</th><th>Assignees</th><th>Title</th></tr>
+{{range .}}<tr><td>{{if .WebURL}}<a href="{{.WebURL}}">{{.ID}}</a>{{else}}{{.ID}}{{end}}</td><td>{{.Status}}</td><td>{{.Urgency}}</td><td>{{.Severity}}</td><td>{{join .Clusters ", "}}</td><td>{{join .Assignees ", "}}</td><td>{{.Title}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+{{end}}<h2>Still open</h2>
+{{template "incidents" .Open}}<h2>Resolved during the shift</h2>
+{{template "incidents" .Resolved}}<h2>Silenced</h2>
+{{template "incidents" .Silenced}}<h2>Noisiest clusters</h2>
+{{if .NoisyClusters}}<table>
+<tr><th>Cluster</th><th>Cluster ID</th><th>Incidents</th><th>Alerts</th><th>Severity</th></tr>
+{{range .NoisyClusters}}<tr><td>{{.ClusterName}}</td><td>{{.ClusterID}}</td><td>{{len .IncidentIDs}}</td><td>{{.Alerts}}</td><td>{{.Severity}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+<h2>Notes</h2>
+{{if .Notes}}<ul>
+{{range .Notes}}<li>{{.CreatedAt}}, {{.Author}} on {{.IncidentID}}: {{.Content}}</li>
+{{end}}</ul>{{else}}<p>None.</p>{{end}}
+</body>
+</html>
+`))
+
+// HTML renders the handoff report as a standalone HTML page, e.g. for an email.
+func (h *Handoff) HTML() (string, error) {
+	var b bytes.Buffer
+	if err := handoffHTML.Execute(&b, h); err != nil {
+		return "", fmt.Errorf("pd.Handoff.HTML(): %v", err)
+	}
+	return b.String(), nil
+}
+
+// handoffIncidents summarises the incidents along with the clusters of their alerts
+func handoffIncidents(incidents []pagerduty.Incident, enriched map[string]EnrichedIncident) []HandoffIncident {
+	h := []HandoffIncident{}
+	for _, i := range incidents {
+		e := enriched[i.ID]
+
+		hi := HandoffIncident{IncidentSummary: NewIncidentSummary(i), Clusters: []string{}}
+		hi.Severity = e.Severity
+		for _, a := range e.Alerts {
+			name := a.ClusterName
+			if name == "" {
+				name = a.ClusterID
+			}
+			if name != "" && name != UnknownCluster && !slices.Contains(hi.Clusters, name) {
+				hi.Clusters = append(hi.Clusters, name)
+			}
+		}
+		h = append(h, hi)
+	}
+	return h
+}
+
+// teamIDs returns the IDs of the configured teams
+func teamIDs(c *Config) []string {
+	var ids []string
+	for _, t := range c.Teams {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
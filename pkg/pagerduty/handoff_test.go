@@ -0,0 +1,311 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+// handoffClient records the incident lists requested and how often the alerts of each incident are fetched
+type handoffClient struct {
+	*fake.Client
+
+	mu     sync.Mutex
+	lists  []pagerduty.ListIncidentsOptions
+	alerts map[string]int
+}
+
+func (c *handoffClient) ListIncidentsWithContext(ctx context.Context, opts pagerduty.ListIncidentsOptions) (*pagerduty.ListIncidentsResponse, error) {
+	c.mu.Lock()
+	c.lists = append(c.lists, opts)
+	c.mu.Unlock()
+	return c.Client.ListIncidentsWithContext(ctx, opts)
+}
+
+func (c *handoffClient) ListIncidentAlertsWithContext(ctx context.Context, id string, opts pagerduty.ListIncidentAlertsOptions) (*pagerduty.ListAlertsResponse, error) {
+	c.mu.Lock()
+	c.alerts[id]++
+	c.mu.Unlock()
+	return c.Client.ListIncidentAlertsWithContext(ctx, id, opts)
+}
+
+var (
+	shiftStart = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	shiftEnd   = time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC)
+)
+
+// newHandoffClient returns incidents of team PTEAM1, of members PUSER1 and PUSER2, on both sides of
+// the shift from 08:00 to 16:00, and incidents of another team
+func newHandoffClient() *handoffClient {
+	c := fake.New()
+	for _, s := range []pagerduty.Service{
+		{APIObject: pagerduty.APIObject{ID: "PSVCE"}, Name: "east", Description: "east cluster"},
+		{APIObject: pagerduty.APIObject{ID: "PSVCW"}, Name: "west", Description: "west cluster"},
+		{APIObject: pagerduty.APIObject{ID: "PSVCN"}, Name: "north", Description: "north cluster"},
+	} {
+		c.AddService(s)
+	}
+
+	team := []pagerduty.APIObject{{ID: "PTEAM1"}}
+	other := []pagerduty.APIObject{{ID: "PTEAM2"}}
+	add := func(id, status, service, created, resolved string, teams []pagerduty.APIObject, assignee string, alerts int) {
+		var incidentAlerts []pagerduty.IncidentAlert
+		for i := range alerts {
+			incidentAlerts = append(incidentAlerts, pagerduty.IncidentAlert{
+				APIObject: pagerduty.APIObject{ID: fmt.Sprintf("%v-%v", id, i)},
+				Severity:  "warning",
+				Body:      map[string]interface{}{"details": map[string]interface{}{"cluster_id": "c-" + service}},
+			})
+		}
+		incident := pagerduty.Incident{
+			APIObject: pagerduty.APIObject{ID: id},
+			Title:     id,
+			Status:    status,
+			Urgency:   "high",
+			Service:   pagerduty.APIObject{ID: service},
+			CreatedAt: created,
+			Teams:     teams,
+		}
+		if resolved != "" {
+			incident.ResolvedAt = resolved
+		}
+		if assignee != "" {
+			incident.Assignments = assignedTo(assignee)
+		}
+		c.AddIncident(incident, incidentAlerts...)
+	}
+
+	// Open incidents are handed off whenever they were triggered
+	add("POPEN1", "triggered", "PSVCE", "2024-04-20T10:00:00Z", "", team, "PUSER1", 1)
+	add("POPEN2", "acknowledged", "PSVCE", "2024-05-01T10:00:00Z", "", team, "PUSER2", 2)
+	add("PSILENT1", "acknowledged", "PSVCW", "2024-05-01T09:00:00Z", "", team, "PSILENT", 1)
+	// Created within the lookback, resolved during the shift
+	add("PRES1", "resolved", "PSVCN", "2024-04-26T08:00:00Z", "2024-05-01T12:00:00Z", team, "", 1)
+	add("PRES2", "resolved", "PSVCN", "2024-05-01T11:00:00Z", "2024-05-01T13:00:00Z", team, "", 3)
+	// Resolved before and after the shift, and created before the lookback
+	add("PEARLY", "resolved", "PSVCN", "2024-05-01T06:00:00Z", "2024-05-01T07:00:00Z", team, "", 1)
+	add("PLATE", "resolved", "PSVCW", "2024-05-01T15:00:00Z", "2024-05-01T16:00:00Z", team, "", 0)
+	add("POLD", "resolved", "PSVCN", "2024-04-20T08:00:00Z", "2024-05-01T10:00:00Z", team, "", 1)
+	// Another team's
+	add("POTHER", "triggered", "PSVCE", "2024-05-01T10:00:00Z", "", other, "PUSER9", 5)
+
+	c.AddNote("POPEN1", pagerduty.IncidentNote{ID: "PNOTE1", CreatedAt: "2024-04-20T11:00:00Z", Content: "Before the shift"})
+	c.AddNote("POPEN2", pagerduty.IncidentNote{ID: "PNOTE2", CreatedAt: "2024-05-01T11:00:00Z", Content: "During the shift", User: pagerduty.APIObject{Summary: "Jane"}})
+
+	return &handoffClient{Client: c, alerts: map[string]int{}}
+}
+
+func handoffConfig(c PagerDutyClient, members ...string) *Config {
+	return &Config{
+		Client:         c,
+		Teams:          []*pagerduty.Team{{APIObject: pagerduty.APIObject{ID: "PTEAM1"}}},
+		TeamsMemberIDs: members,
+		SilentUser:     &pagerduty.User{APIObject: pagerduty.APIObject{ID: "PSILENT"}},
+	}
+}
+
+func handoffIDs(incidents []HandoffIncident) string {
+	var ids []string
+	for _, i := range incidents {
+		ids = append(ids, i.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestGetHandoff(t *testing.T) {
+	c := newHandoffClient()
+
+	h, err := GetHandoffWithContext(context.Background(), handoffConfig(c, "PUSER1", "PUSER2"), HandoffOptions{Since: shiftStart, Until: shiftEnd, TopClusters: 2})
+	if err != nil {
+		t.Fatalf("GetHandoff() error = %v", err)
+	}
+
+	if len(h.Errors) != 0 {
+		t.Errorf("errors = %v, want none", h.Errors)
+	}
+
+	if got := handoffIDs(h.Open); got != "POPEN1,POPEN2" {
+		t.Errorf("open = %v, want POPEN1,POPEN2", got)
+	}
+	if got := handoffIDs(h.Silenced); got != "PSILENT1" {
+		t.Errorf("silenced = %v, want PSILENT1", got)
+	}
+	// POLD was resolved during the shift, but created too long before it to be listed
+	if got := handoffIDs(h.Resolved); got != "PRES1,PRES2" {
+		t.Errorf("resolved = %v, want PRES1,PRES2", got)
+	}
+	if h.Triggered != 4 {
+		t.Errorf("triggered = %v, want POPEN2, PSILENT1, PRES2 and PLATE", h.Triggered)
+	}
+	if len(h.Open) == 2 && strings.Join(h.Open[1].Clusters, ",") != "east" {
+		t.Errorf("POPEN2 clusters = %v, want east", h.Open[1].Clusters)
+	}
+
+	// The noisiest clusters of the incidents triggered during the shift, west left out as third
+	var noisy []string
+	for _, g := range h.NoisyClusters {
+		noisy = append(noisy, g.ClusterName)
+	}
+	if strings.Join(noisy, ",") != "north,east" || h.NoisyClusters[0].Alerts != 3 {
+		t.Errorf("noisy clusters = %+v, want north with 3 alerts then east", h.NoisyClusters)
+	}
+
+	if len(h.Notes) != 1 || h.Notes[0].ID != "PNOTE2" {
+		t.Errorf("notes = %+v, want PNOTE2 only", h.Notes)
+	}
+
+	// Incidents in several lists are only enriched once
+	for id, n := range c.alerts {
+		if n != 1 {
+			t.Errorf("alerts of %v fetched %v times, want once", id, n)
+		}
+	}
+
+	var lookback bool
+	for _, o := range c.lists {
+		if len(o.UserIDs) == 0 && len(o.TeamIDs) == 0 {
+			t.Errorf("incidents listed across the account with %+v", o)
+		}
+		if o.Since == "2024-04-24T08:00:00Z" && o.Until == "2024-05-01T16:00:00Z" {
+			lookback = true
+		}
+	}
+	if !lookback {
+		t.Errorf("resolved incidents not listed from 7 days before the shift, lists = %+v", c.lists)
+	}
+}
+
+func TestGetHandoffWithoutMembers(t *testing.T) {
+	c := newHandoffClient()
+
+	h, err := GetHandoffWithContext(context.Background(), handoffConfig(c), HandoffOptions{Since: shiftStart, Until: shiftEnd})
+	if err != nil {
+		t.Fatalf("GetHandoff() error = %v", err)
+	}
+	// Those of the team rather than of the whole account
+	if got := handoffIDs(h.Open); got != "POPEN1,POPEN2,PSILENT1" {
+		t.Errorf("open = %v, want the open incidents of PTEAM1", got)
+	}
+
+	cfg := handoffConfig(c)
+	cfg.Teams = nil
+	if _, err := GetHandoffWithContext(context.Background(), cfg, HandoffOptions{Since: shiftStart, Until: shiftEnd}); err == nil {
+		t.Errorf("GetHandoff() without members or teams succeeded")
+	}
+
+	if _, err := GetHandoffWithContext(context.Background(), handoffConfig(c, "PUSER1"), HandoffOptions{Since: shiftEnd, Until: shiftStart}); err == nil {
+		t.Errorf("GetHandoff() of a shift ending before it starts succeeded")
+	}
+}
+
+// newHandoff returns a report with an incident in each list but the resolved ones
+func newHandoff() *Handoff {
+	open := HandoffIncident{
+		IncidentSummary: IncidentSummary{ID: "PINC1", Title: "Disk <full> | node-3", Status: "acknowledged", Urgency: "high", Severity: "critical", Assignees: []string{"Jane Doe"}, WebURL: "https://example.pagerduty.com/incidents/PINC1"},
+		Clusters:        []string{"east", "west"},
+	}
+	silenced := HandoffIncident{
+		IncidentSummary: IncidentSummary{ID: "PINC2", Title: "Certificate expiring", Status: "triggered", Urgency: "low", Assignees: []string{"Silent Test"}},
+		Clusters:        []string{},
+	}
+
+	return &Handoff{
+		Since:         "2024-05-01T08:00:00Z",
+		Until:         "2024-05-01T16:00:00Z",
+		Open:          []HandoffIncident{open},
+		Resolved:      []HandoffIncident{},
+		Silenced:      []HandoffIncident{silenced},
+		NoisyClusters: []ClusterGroup{{ClusterID: "c-1", ClusterName: "east", IncidentIDs: []string{"PINC1"}, Alerts: 3, Severity: "critical"}},
+		Triggered:     2,
+		Notes:         []NoteSummary{{IncidentID: "PINC1", Author: "Jane Doe", Content: "Cleaning up\nlogs", CreatedAt: "2024-05-01T11:00:00Z"}},
+	}
+}
+
+func TestHandoffMarkdown(t *testing.T) {
+	want := `# Shift handoff
+
+2024-05-01T08:00:00Z to 2024-05-01T16:00:00Z: 2 incident(s) triggered, 0 resolved, 1 still open, 1 silenced.
+
+## Still open
+
+| Incident | Status | Urgency | Severity | Clusters | Assignees | Title |
+|---|---|---|---|---|---|---|
+| [PINC1](https://example.pagerduty.com/incidents/PINC1) | acknowledged | high | critical | east, west | Jane Doe | Disk &lt;full&gt; \| node-3 |
+
+## Resolved during the shift
+
+None.
+
+## Silenced
+
+| Incident | Status | Urgency | Severity | Clusters | Assignees | Title |
+|---|---|---|---|---|---|---|
+| PINC2 | triggered | low |  |  | Silent Test | Certificate expiring |
+
+## Noisiest clusters
+
+| Cluster | Cluster ID | Incidents | Alerts | Severity |
+|---|---|---|---|---|
+| east | c-1 | 1 | 3 | critical |
+
+## Notes
+
+- 2024-05-01T11:00:00Z, Jane Doe on PINC1: Cleaning up<br>logs
+`
+
+	if got := newHandoff().Markdown(); got != want {
+		t.Errorf("Markdown() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestHandoffText(t *testing.T) {
+	want := `Shift handoff 2024-05-01T08:00:00Z to 2024-05-01T16:00:00Z
+2 incident(s) triggered, 0 resolved, 1 still open, 1 silenced
+
+Still open (1)
+  PINC1  acknowledged high  Disk <full> | node-3 [east, west]
+
+Resolved during the shift (0)
+
+Silenced (1)
+  PINC2  triggered    low   Certificate expiring
+
+Noisiest clusters (1)
+  east  3 alert(s) in 1 incident(s)
+
+Notes (1)
+  2024-05-01T11:00:00Z Jane Doe on PINC1: Cleaning up logs
+`
+
+	if got := newHandoff().Text(); got != want {
+		t.Errorf("Text() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestHandoffHTML(t *testing.T) {
+	got, err := newHandoff().HTML()
+	if err != nil {
+		t.Fatalf("HTML() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`<p>2024-05-01T08:00:00Z to 2024-05-01T16:00:00Z: 2 incident(s) triggered, 0 resolved, 1 still open, 1 silenced.</p>`,
+		`<td><a href="https://example.pagerduty.com/incidents/PINC1">PINC1</a></td>`,
+		`<td>Disk &lt;full&gt; | node-3</td>`,
+		`<td>east, west</td>`,
+		"<h2>Resolved during the shift</h2>\n<p>None.</p>",
+		`<td>PINC2</td>`,
+		`<tr><td>east</td><td>c-1</td><td>1</td><td>3</td><td>critical</td></tr>`,
+		`<li>2024-05-01T11:00:00Z, Jane Doe on PINC1: Cleaning up`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML() does not contain %q:\n%v", want, got)
+		}
+	}
+}
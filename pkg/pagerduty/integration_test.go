@@ -0,0 +1,76 @@
+package pd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+// TestEndToEnd runs the config, list, acknowledge and note flow of the CLI through the real PagerDuty
+// client against the emulated API.
+func TestEndToEnd(t *testing.T) {
+	ctx := context.Background()
+
+	f, err := fake.LoadFixtures("fake/testdata/basic.json")
+	if err != nil {
+		t.Fatalf("LoadFixtures() error = %v", err)
+	}
+	srv := fake.NewServer(f)
+	defer srv.Close()
+
+	c, err := NewConfigWithClient(ctx, NewClient("test-token", srv.URL), []string{"PTEAM01"}, "PSILENT", nil)
+	if err != nil {
+		t.Fatalf("NewConfigWithClient() error = %v", err)
+	}
+	if c.CurrentUser.ID != "PUSER01" || c.SilentUser.ID != "PSILENT" || len(c.TeamsMemberIDs) != 2 {
+		t.Fatalf("NewConfigWithClient() = current user %v, silent user %v, members %v", c.CurrentUser.ID, c.SilentUser.ID, c.TeamsMemberIDs)
+	}
+
+	opts := NewListIncidentOptsFromDefaults()
+	opts.UserIDs = c.TeamsMemberIDs
+	incidents, err := GetIncidentsWithContext(ctx, c.Client, opts)
+	if err != nil {
+		t.Fatalf("GetIncidents() error = %v", err)
+	}
+	if len(incidents) != 1 || incidents[0].ID != "PINC001" || incidents[0].Status != "triggered" {
+		t.Fatalf("GetIncidents() = %+v, want the triggered incident PINC001", incidents)
+	}
+
+	result, err := AcknowledgeIncidentWithContext(ctx, c.Client, []*pagerduty.Incident{&incidents[0]}, c.CurrentUser)
+	if err != nil {
+		t.Fatalf("AcknowledgeIncident() error = %v", err)
+	}
+	if applied := result.Applied(); len(applied) != 1 || applied[0].Status != "acknowledged" {
+		t.Errorf("AcknowledgeIncident() applied = %+v, want PINC001 acknowledged", applied)
+	}
+
+	if _, err := PostNoteWithContext(ctx, c.Client, "PINC001", c.CurrentUser, "Looking into it"); err != nil {
+		t.Fatalf("PostNote() error = %v", err)
+	}
+
+	// Read the state back from the API rather than trusting the responses above
+	incident, err := GetIncidentWithContext(ctx, c.Client, "PINC001")
+	if err != nil {
+		t.Fatalf("GetIncident() error = %v", err)
+	}
+	if incident.Status != "acknowledged" {
+		t.Errorf("incident status = %v, want acknowledged", incident.Status)
+	}
+	if len(incident.Assignments) != 1 || incident.Assignments[0].Assignee.ID != "PUSER01" {
+		t.Errorf("incident assignments = %+v, want PUSER01", incident.Assignments)
+	}
+	if len(incident.Acknowledgements) != 1 || incident.Acknowledgements[0].Acknowledger.ID != "PUSER01" {
+		t.Errorf("incident acknowledgements = %+v, want PUSER01", incident.Acknowledgements)
+	}
+
+	notes, err := GetNotesWithContext(ctx, c.Client, "PINC001")
+	if err != nil {
+		t.Fatalf("GetNotes() error = %v", err)
+	}
+	last := notes[len(notes)-1]
+	if last.Content != "Looking into it" || last.User.ID != "PUSER01" || last.CreatedAt == "" {
+		t.Errorf("last note = %+v, want Looking into it by PUSER01", last)
+	}
+}
@@ -0,0 +1,109 @@
+package pd
+
+import (
+	"sort"
+	"strings"
+)
+
+// parseFiring parses the `firing` text Alertmanager sends to PagerDuty into its labels and annotations:
+//
+//	Labels:
+//	 - alertname = KubeAPIErrorBudgetBurn
+//	 - severity = critical
+//	Annotations:
+//	 - summary = The API server is burning too much error budget.
+//
+// When several alerts are grouped in one payload, the first value of each key wins.
+func parseFiring(firing string) (labels, annotations map[string]string) {
+	var section map[string]string
+
+	for _, line := range strings.Split(firing, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "Labels:":
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			section = labels
+
+		case trimmed == "Annotations:":
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			section = annotations
+
+		case strings.HasPrefix(trimmed, "- ") && section != nil:
+			key, value, ok := strings.Cut(strings.TrimPrefix(trimmed, "- "), " = ")
+			if !ok {
+				continue
+			}
+			key = strings.TrimSpace(key)
+			if _, seen := section[key]; !seen {
+				section[key] = strings.TrimSpace(value)
+			}
+
+		default:
+			// Anything else, such as the `Source:` line, ends the current section
+			section = nil
+		}
+	}
+
+	return labels, annotations
+}
+
+// SortedKeys returns the keys of a label or annotation map in alphabetical order.
+func SortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FormatLabels formats a label or annotation map as a sorted, comma separated list of key=value pairs.
+func FormatLabels(m map[string]string) string {
+	var pairs []string
+	for _, k := range SortedKeys(m) {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// LabelSelector selects alerts on their labels and annotations. Each term is either `key`, which
+// requires the key to be set, `key=value` or `key!=value`.
+type LabelSelector []string
+
+// Matches reports whether every term of the selector holds for the labels and annotations of the alert.
+func (s LabelSelector) Matches(a Alert) bool {
+	for _, term := range s {
+		if !matchesTerm(term, a) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesTerm(term string, a Alert) bool {
+	lookup := func(key string) (string, bool) {
+		if v, ok := a.AlertLabels[key]; ok {
+			return v, true
+		}
+		v, ok := a.Annotations[key]
+		return v, ok
+	}
+
+	if key, value, ok := strings.Cut(term, "!="); ok {
+		v, found := lookup(key)
+		return !found || v != value
+	}
+
+	if key, value, ok := strings.Cut(term, "="); ok {
+		v, found := lookup(key)
+		return found && v == value
+	}
+
+	_, found := lookup(term)
+	return found
+}
@@ -0,0 +1,109 @@
+package pd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFiring(t *testing.T) {
+	tests := []struct {
+		name            string
+		firing          string
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
+	}{
+		{
+			name: "single alert",
+			firing: `Labels:
+ - alertname = KubePodCrashLooping
+ - container = manager
+ - namespace = openshift-monitoring
+ - severity = warning
+Annotations:
+ - description = Pod openshift-monitoring/cluster-monitoring-operator-5d4b8 (manager) is in waiting state (reason: "CrashLoopBackOff").
+ - runbook_url = https://github.com/openshift/runbooks/blob/master/alerts/cluster-monitoring-operator/KubePodCrashLooping.md
+ - summary = Pod is crash looping.
+Source: https://console-openshift-console.apps.prod-east-1.example.com/monitoring/graph?g0.expr=max_over_time%28kube_pod_container_status_waiting_reason%5B5m%5D%29+%3E%3D+1
+`,
+			wantLabels: map[string]string{
+				"alertname": "KubePodCrashLooping",
+				"container": "manager",
+				"namespace": "openshift-monitoring",
+				"severity":  "warning",
+			},
+			wantAnnotations: map[string]string{
+				"description": `Pod openshift-monitoring/cluster-monitoring-operator-5d4b8 (manager) is in waiting state (reason: "CrashLoopBackOff").`,
+				"runbook_url": "https://github.com/openshift/runbooks/blob/master/alerts/cluster-monitoring-operator/KubePodCrashLooping.md",
+				"summary":     "Pod is crash looping.",
+			},
+		},
+		{
+			name: "grouped alerts keep the first value of each key",
+			firing: `Labels:
+ - alertname = KubeAPIErrorBudgetBurn
+ - long = 1h
+ - severity = critical
+ - short = 5m
+Annotations:
+ - summary = The API server is burning too much error budget.
+Source: https://console.example.com/monitoring/graph?g0.expr=sum%28apiserver_request%3Aburnrate1h%29
+Labels:
+ - alertname = KubeAPIErrorBudgetBurn
+ - long = 6h
+ - severity = warning
+ - short = 30m
+Annotations:
+ - summary = The API server is burning too much error budget.
+Source: https://console.example.com/monitoring/graph?g0.expr=sum%28apiserver_request%3Aburnrate6h%29
+`,
+			wantLabels: map[string]string{
+				"alertname": "KubeAPIErrorBudgetBurn",
+				"long":      "1h",
+				"severity":  "critical",
+				"short":     "5m",
+			},
+			wantAnnotations: map[string]string{
+				"summary": "The API server is burning too much error budget.",
+			},
+		},
+		{
+			name: "values containing the separator",
+			firing: `Labels:
+ - alertname = etcdMembersDown
+ - job = etcd
+Annotations:
+ - message = members are down (count = 1)
+`,
+			wantLabels:      map[string]string{"alertname": "etcdMembersDown", "job": "etcd"},
+			wantAnnotations: map[string]string{"message": "members are down (count = 1)"},
+		},
+		{
+			name: "lines outside a section or without a value are skipped",
+			firing: `[FIRING:1] ClusterOperatorDown
+ - ignored = before any section
+Labels:
+ - alertname = ClusterOperatorDown
+ - malformed
+Source: https://console.example.com
+ - ignored = after the source
+`,
+			wantLabels: map[string]string{"alertname": "ClusterOperatorDown"},
+		},
+		{
+			name:   "no firing text",
+			firing: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, annotations := parseFiring(tt.firing)
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("parseFiring() labels = %v, want %v", labels, tt.wantLabels)
+			}
+			if !reflect.DeepEqual(annotations, tt.wantAnnotations) {
+				t.Errorf("parseFiring() annotations = %v, want %v", annotations, tt.wantAnnotations)
+			}
+		})
+	}
+}
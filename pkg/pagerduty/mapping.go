@@ -0,0 +1,233 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PagerDuty/go-pagerduty"
+	config "github.com/aliceh/alertops/pkg/config"
+)
+
+// RegisterAlertMappings registers an alert parser for each alert mapping of the configuration, in
+// place of the mappings of the configuration loaded before, if any. Nothing is replaced if one of
+// the mappings is invalid.
+func RegisterAlertMappings(mappings []config.AlertMapping) error {
+	var mapped []AlertParser
+	for _, m := range mappings {
+		p, err := NewMappingParser(m)
+		if err != nil {
+			return err
+		}
+		mapped = append(mapped, p)
+	}
+
+	return replaceMappingParsers(mapped)
+}
+
+// NewMappingParser builds an alert parser from a declarative alert mapping.
+func NewMappingParser(m config.AlertMapping) (AlertParser, error) {
+	if m.Name == "" {
+		return AlertParser{}, fmt.Errorf("pd.NewMappingParser(): alert mapping has no name")
+	}
+	if len(m.Match) == 0 {
+		return AlertParser{}, fmt.Errorf("pd.NewMappingParser(): alert mapping `%v` has no match rules", m.Name)
+	}
+
+	type rule struct {
+		key   string
+		value *regexp.Regexp
+	}
+
+	var rules []rule
+	for _, r := range m.Match {
+		if r.Key == "" {
+			return AlertParser{}, fmt.Errorf("pd.NewMappingParser(): alert mapping `%v` has a match rule without a key", m.Name)
+		}
+
+		var value *regexp.Regexp
+		if r.Value != "" {
+			var err error
+			value, err = regexp.Compile(r.Value)
+			if err != nil {
+				return AlertParser{}, fmt.Errorf("pd.NewMappingParser(): invalid match value for `%v` in alert mapping `%v`: %v", r.Key, m.Name, err)
+			}
+		}
+
+		rules = append(rules, rule{key: r.Key, value: value})
+	}
+
+	type field struct {
+		name       string
+		extraction *config.Extraction
+		path       []pathStep
+		regex      *regexp.Regexp
+		set        func(a *Alert, v string)
+	}
+
+	fields := []field{
+		{name: "cluster_id", extraction: m.Fields.ClusterID, set: func(a *Alert, v string) { a.ClusterID = v }},
+		{name: "cluster_name", extraction: m.Fields.ClusterName, set: func(a *Alert, v string) { a.ClusterName = v }},
+		{name: "sop", extraction: m.Fields.Sop, set: func(a *Alert, v string) { a.Sop = v }},
+		{name: "severity", extraction: m.Fields.Severity, set: func(a *Alert, v string) { a.Severity = v }},
+		{name: "labels", extraction: m.Fields.Labels, set: func(a *Alert, v string) { a.Labels = v }},
+	}
+
+	var extractions []field
+	for _, f := range fields {
+		if f.extraction == nil {
+			continue
+		}
+
+		var err error
+		f.path, err = parsePath(f.extraction.Path)
+		if err != nil {
+			return AlertParser{}, fmt.Errorf("pd.NewMappingParser(): invalid path for `%v` in alert mapping `%v`: %v", f.name, m.Name, err)
+		}
+
+		if f.extraction.Regex != "" {
+			f.regex, err = regexp.Compile(f.extraction.Regex)
+			if err != nil {
+				return AlertParser{}, fmt.Errorf("pd.NewMappingParser(): invalid regex for `%v` in alert mapping `%v`: %v", f.name, m.Name, err)
+			}
+		}
+
+		extractions = append(extractions, f)
+	}
+
+	return AlertParser{
+		Name: m.Name,
+		Match: func(details map[string]interface{}) bool {
+			for _, r := range rules {
+				v, ok := details[r.key]
+				if !ok || v == nil {
+					return false
+				}
+				if r.value != nil && !r.value.MatchString(fmt.Sprint(v)) {
+					return false
+				}
+			}
+			return true
+		},
+		Extract: func(ctx context.Context, c PagerDutyClient, alert *pagerduty.IncidentAlert, details map[string]interface{}, a *Alert) error {
+			r := &detailReader{details: details}
+
+			for _, f := range extractions {
+				v, ok := lookupPath(details, f.path)
+				if !ok {
+					r.fail(f.extraction.Path, ErrFieldMissing)
+					continue
+				}
+
+				s := fmt.Sprint(v)
+				if f.regex != nil {
+					match := f.regex.FindStringSubmatch(s)
+					if match == nil {
+						r.fail(f.extraction.Path, fmt.Errorf("%w: no match for `%v`", ErrFieldMistyped, f.regex))
+						continue
+					}
+					s = match[0]
+					if len(match) > 1 {
+						s = match[1]
+					}
+				}
+
+				f.set(a, s)
+			}
+
+			return r.err()
+		},
+	}, nil
+}
+
+// pathStep is either a map key or, if key is empty, a slice index
+type pathStep struct {
+	key   string
+	index int
+}
+
+// parsePath parses a JSONPath-style path such as `$.Trigger.Dimensions[0].value` or
+// `$['last healthy check-in']`. The leading `$` is optional.
+func parsePath(path string) ([]pathStep, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	var steps []pathStep
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+
+		case '[':
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated `[`")
+			}
+
+			inner := path[1:end]
+			path = path[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				// An empty key would be taken for an index
+				key := inner[1 : len(inner)-1]
+				if key == "" {
+					return nil, fmt.Errorf("empty key `%v`", inner)
+				}
+				steps = append(steps, pathStep{key: key})
+				continue
+			}
+
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index `%v`", inner)
+			}
+			steps = append(steps, pathStep{index: index})
+
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			steps = append(steps, pathStep{key: path[:end]})
+			path = path[end:]
+		}
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	return steps, nil
+}
+
+// lookupPath follows the path through nested maps and slices of the alert details
+func lookupPath(v interface{}, path []pathStep) (interface{}, bool) {
+	for _, step := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			if step.key == "" {
+				return nil, false
+			}
+			next, ok := node[step.key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+
+		case []interface{}:
+			if step.key != "" || step.index >= len(node) {
+				return nil, false
+			}
+			v = node[step.index]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return v, v != nil
+}
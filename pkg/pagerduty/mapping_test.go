@@ -0,0 +1,154 @@
+package pd
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	config "github.com/aliceh/alertops/pkg/config"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+// withMappings registers the alert mappings for the duration of the test
+func withMappings(t *testing.T, mappings ...config.AlertMapping) {
+	t.Helper()
+
+	if err := RegisterAlertMappings(mappings); err != nil {
+		t.Fatalf("RegisterAlertMappings() error = %v", err)
+	}
+	t.Cleanup(func() { RegisterAlertMappings(nil) })
+}
+
+func mapping(name, key string) config.AlertMapping {
+	return config.AlertMapping{
+		Name:   name,
+		Match:  []config.AlertMatch{{Key: key}},
+		Fields: config.AlertMappingFields{ClusterID: &config.Extraction{Path: "$." + key}},
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []pathStep
+		wantErr bool
+	}{
+		{path: "$.Trigger.Dimensions[0].value", want: []pathStep{{key: "Trigger"}, {key: "Dimensions"}, {index: 0}, {key: "value"}}},
+		{path: "Trigger.Dimensions", want: []pathStep{{key: "Trigger"}, {key: "Dimensions"}}},
+		{path: " $.firing ", want: []pathStep{{key: "firing"}}},
+		{path: "$['last healthy check-in']", want: []pathStep{{key: "last healthy check-in"}}},
+		{path: `$["cluster.id"].name`, want: []pathStep{{key: "cluster.id"}, {key: "name"}}},
+		{path: "$.alerts[2][10]", want: []pathStep{{key: "alerts"}, {index: 2}, {index: 10}}},
+		{path: "$[0]", want: []pathStep{{index: 0}}},
+		{path: "$", wantErr: true},
+		{path: "", wantErr: true},
+		{path: "$.", wantErr: true},
+		{path: "$['']", wantErr: true},
+		{path: `$.a[""]`, wantErr: true},
+		{path: "$.a[0", wantErr: true},
+		{path: "$.a[-1]", wantErr: true},
+		{path: "$.a[x]", wantErr: true},
+		{path: "$.a['b]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parsePath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePath() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePath() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	var details interface{}
+	err := json.Unmarshal([]byte(`{
+		"Trigger": {"Dimensions": [{"name": "ClusterId", "value": "prod-east-1"}, {"name": "Region", "value": "us-east-1"}]},
+		"cluster.id": "c-123",
+		"last healthy check-in": "2024-05-01T12:00:00Z",
+		"alerts": [["a", "b"]],
+		"empty": null
+	}`), &details)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		want   interface{}
+		wantOK bool
+	}{
+		{path: "$.Trigger.Dimensions[0].value", want: "prod-east-1", wantOK: true},
+		{path: "$.Trigger.Dimensions[1].name", want: "Region", wantOK: true},
+		{path: "$['cluster.id']", want: "c-123", wantOK: true},
+		{path: "$['last healthy check-in']", want: "2024-05-01T12:00:00Z", wantOK: true},
+		{path: "$.alerts[0][1]", want: "b", wantOK: true},
+		{path: "$.Trigger.Dimensions[2].value"},
+		{path: "$.alerts[0][2]"},
+		{path: "$.cluster.id"},
+		{path: "$.Trigger.Missing"},
+		{path: "$.Trigger[0]"},
+		{path: "$.Trigger.Dimensions.value"},
+		{path: "$.alerts[0][0].name"},
+		{path: "$.empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := parsePath(tt.path)
+			if err != nil {
+				t.Fatalf("parsePath() error = %v", err)
+			}
+
+			got, ok := lookupPath(details, path)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("lookupPath() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRegisterAlertMappings(t *testing.T) {
+	builtin := AlertParsers()
+	withMappings(t, mapping("alpha", "a"), mapping("beta", "b"))
+
+	if got := strings.Join(AlertParsers(), ","); got != "beta,alpha,"+strings.Join(builtin, ",") {
+		t.Errorf("AlertParsers() = %v, want the mappings before the built-in parsers", got)
+	}
+
+	// Loading the configuration again replaces the mappings, whether they changed or not
+	withMappings(t, mapping("alpha", "c"))
+	if got := strings.Join(AlertParsers(), ","); got != "alpha,"+strings.Join(builtin, ",") {
+		t.Errorf("AlertParsers() = %v, want the mappings of the last configuration only", got)
+	}
+
+	var a Alert
+	if err := a.ParseAlertDataWithContext(context.Background(), fake.New(), newAlert(map[string]interface{}{"c": "c-123"})); err != nil || a.Parser != "alpha" || a.ClusterID != "c-123" {
+		t.Errorf("ParseAlertData() = parser %v, cluster %v, error %v, want the new alpha", a.Parser, a.ClusterID, err)
+	}
+	a = Alert{}
+	a.ParseAlertDataWithContext(context.Background(), fake.New(), newAlert(map[string]interface{}{"b": "c-123"}))
+	if a.Parser != ParserAlertmanager {
+		t.Errorf("ParseAlertData() parser = %v, want the removed beta no longer used", a.Parser)
+	}
+
+	for name, mappings := range map[string][]config.AlertMapping{
+		"built-in name":      {mapping(ParserCHGM, "a")},
+		"fallback name":      {mapping(ParserAlertmanager, "a")},
+		"duplicate mappings": {mapping("gamma", "a"), mapping("gamma", "b")},
+		"invalid mapping":    {mapping("gamma", "a"), {Name: "delta"}},
+	} {
+		if err := RegisterAlertMappings(mappings); err == nil {
+			t.Errorf("RegisterAlertMappings() with a %v succeeded", name)
+		}
+		if got := strings.Join(AlertParsers(), ","); got != "alpha,"+strings.Join(builtin, ",") {
+			t.Errorf("AlertParsers() after a failed registration with a %v = %v, want the previous mappings kept", name, got)
+		}
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/PagerDuty/go-pagerduty"
-	utils "github.com/aliceh/alertops/pkg/utils"
 )
 
 const (
@@ -14,23 +13,32 @@ const (
 	defaultOffset    = 0
 )
 
+// Alert is an alert parsed from a PagerDuty incident alert. The json/yaml field names are part of
+// the structured output of the CLI, so they must not be renamed or removed.
 type Alert struct {
-	IncidentID  string
-	AlertID     string
-	ClusterID   string
-	ClusterName string
-	Name        string
-	Console     string
-	Hostname    string
-	IP          string
-	Labels      string
-	LastCheckIn string
-	Severity    string
-	Status      string
-	Sop         string
-	Token       string
-	Tags        string
-	WebURL      string
+	IncidentID  string `json:"incident_id" yaml:"incident_id"`
+	AlertID     string `json:"alert_id" yaml:"alert_id"`
+	ClusterID   string `json:"cluster_id" yaml:"cluster_id"`
+	ClusterName string `json:"cluster_name" yaml:"cluster_name"`
+	Name        string `json:"name" yaml:"name"`
+	Console     string `json:"console" yaml:"console"`
+	Hostname    string `json:"hostname" yaml:"hostname"`
+	IP          string `json:"ip" yaml:"ip"`
+	// Labels is the raw Alertmanager `firing` text, parsed into AlertLabels and Annotations
+	Labels      string `json:"labels" yaml:"labels"`
+	LastCheckIn string `json:"last_check_in" yaml:"last_check_in"`
+	Severity    string `json:"severity" yaml:"severity"`
+	Status      string `json:"status" yaml:"status"`
+	Sop         string `json:"sop" yaml:"sop"`
+	Token       string `json:"token" yaml:"token"`
+	Tags        string `json:"tags" yaml:"tags"`
+	WebURL      string `json:"web_url" yaml:"web_url"`
+	Parser      string `json:"parser" yaml:"parser"`
+	Partial     bool   `json:"partial" yaml:"partial"`
+	ParseError  string `json:"parse_error,omitempty" yaml:"parse_error,omitempty"`
+
+	AlertLabels map[string]string `json:"alert_labels,omitempty" yaml:"alert_labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
 }
 
 var defaultIncidentStatues = []string{"triggered", "acknowledged"}
@@ -41,19 +49,30 @@ type PagerDutyClientInterface interface {
 	CreateIncidentNoteWithContext(ctx context.Context, id string, note pagerduty.IncidentNote) (*pagerduty.IncidentNote, error)
 	GetCurrentUserWithContext(ctx context.Context, opts pagerduty.GetCurrentUserOptions) (*pagerduty.User, error)
 	GetIncidentWithContext(ctx context.Context, id string) (*pagerduty.Incident, error)
-	GetService(serviceID string, opts *pagerduty.GetServiceOptions) (*pagerduty.Service, error)
+	GetServiceWithContext(ctx context.Context, serviceID string, opts *pagerduty.GetServiceOptions) (*pagerduty.Service, error)
 	GetTeamWithContext(ctx context.Context, id string) (*pagerduty.Team, error)
 	ListMembersWithContext(ctx context.Context, id string, opts pagerduty.ListTeamMembersOptions) (*pagerduty.ListTeamMembersResponse, error)
 	GetUserWithContext(ctx context.Context, id string, opts pagerduty.GetUserOptions) (*pagerduty.User, error)
 	ListIncidentAlertsWithContext(ctx context.Context, id string, opts pagerduty.ListIncidentAlertsOptions) (*pagerduty.ListAlertsResponse, error)
 	ListIncidentsWithContext(ctx context.Context, opts pagerduty.ListIncidentsOptions) (*pagerduty.ListIncidentsResponse, error)
+	ListIncidentLogEntriesWithContext(ctx context.Context, id string, opts pagerduty.ListIncidentLogEntriesOptions) (*pagerduty.ListIncidentLogEntriesResponse, error)
 	ListIncidentNotesWithContext(ctx context.Context, id string) ([]pagerduty.IncidentNote, error)
+	ListOnCallsWithContext(ctx context.Context, opts pagerduty.ListOnCallOptions) (*pagerduty.ListOnCallsResponse, error)
+	ListPrioritiesWithContext(ctx context.Context, opts pagerduty.ListPrioritiesOptions) (*pagerduty.ListPrioritiesResponse, error)
 	ManageIncidentsWithContext(ctx context.Context, email string, opts []pagerduty.ManageIncidentsOptions) (*pagerduty.ListIncidentsResponse, error)
+	MergeIncidentsWithContext(ctx context.Context, from, id string, sourceIncidents []pagerduty.MergeIncidentsOptions) (*pagerduty.Incident, error)
+	SnoozeIncidentWithContext(ctx context.Context, from, id string, duration uint) (*pagerduty.Incident, error)
+	UpdateIncidentUrgencyWithContext(ctx context.Context, from, id, urgency string) (*pagerduty.Incident, error)
 }
 
-// GetClusterName interacts with the PD service endpoint and returns the cluster name string.
+// GetClusterName calls GetClusterNameWithContext with a background context.
 func GetClusterName(servideID string, c PagerDutyClient) (string, error) {
-	service, err := c.GetService(servideID, &pagerduty.GetServiceOptions{})
+	return GetClusterNameWithContext(context.Background(), servideID, c)
+}
+
+// GetClusterNameWithContext interacts with the PD service endpoint and returns the cluster name string.
+func GetClusterNameWithContext(ctx context.Context, servideID string, c PagerDutyClient) (string, error) {
+	service, err := c.GetServiceWithContext(ctx, servideID, &pagerduty.GetServiceOptions{})
 
 	if err != nil {
 		return "", err
@@ -64,64 +83,68 @@ func GetClusterName(servideID string, c PagerDutyClient) (string, error) {
 	return clusterName, nil
 }
 
-// ParseAlertData parses a pagerduty alert data into the Alert struct.
+// ParseAlertData calls ParseAlertDataWithContext with a background context.
 func (a *Alert) ParseAlertData(c PagerDutyClient, alert *pagerduty.IncidentAlert) (err error) {
+	return a.ParseAlertDataWithContext(context.Background(), c, alert)
+}
+
+// ParseAlertDataWithContext parses a pagerduty alert data into the Alert struct, using the first
+// registered AlertParser matching the alert details. It never panics: if some fields cannot be read,
+// the others are still filled in, the alert is marked as partial and a *ParseError is returned.
+func (a *Alert) ParseAlertDataWithContext(ctx context.Context, c PagerDutyClient, alert *pagerduty.IncidentAlert) (err error) {
 	a.IncidentID = alert.Incident.ID
 	a.AlertID = alert.ID
 	a.Name = alert.Summary
 	a.Status = alert.Status
 	a.WebURL = alert.HTMLURL
 
-	// Check if the alert is of type 'Missing cluster'
-	isCHGM := alert.Body["details"].(map[string]interface{})["notes"]
-
-	// Check if the alert is of type 'Certificate is expiring'
-	isCertExpiring := alert.Body["details"].(map[string]interface{})["hostname"]
-
-	if isCHGM != nil {
-		notes := strings.Split(fmt.Sprint(alert.Body["details"].(map[string]interface{})["notes"]), "\n")
+	defer func() {
+		// Parsers and mappings only fill in the raw firing text, whatever the alert source
+		if a.AlertLabels == nil && a.Annotations == nil && a.Labels != "" {
+			a.AlertLabels, a.Annotations = parseFiring(a.Labels)
+		}
 
-		a.ClusterID = strings.Replace(notes[0], "cluster_id: ", "", 1)
-		a.ClusterName = strings.Split(fmt.Sprint(alert.Body["details"].(map[string]interface{})["name"]), ".")[0]
+		a.Severity = resolveSeverity(a.Severity, a.AlertLabels["severity"], alert.Severity)
 
-		lastCheckIn := fmt.Sprint(alert.Body["details"].(map[string]interface{})["last healthy check-in"])
-		a.LastCheckIn, err = utils.FormatTimestamp(lastCheckIn)
+		// If there's no cluster ID related to the given alert
+		if a.ClusterID == "" {
+			a.ClusterID = "N/A"
+		}
 
 		if err != nil {
-			return err
+			a.Partial = true
+			a.ParseError = err.Error()
+			err = &ParseError{AlertID: alert.ID, Parser: a.Parser, Err: err}
 		}
+	}()
 
-		a.Token = fmt.Sprint(alert.Body["details"].(map[string]interface{})["token"])
-		a.Tags = fmt.Sprint(alert.Body["details"].(map[string]interface{})["tags"])
-		a.Sop = strings.Replace(notes[1], "runbook: ", "", 1)
+	details, err := alertDetails(alert)
+	if err != nil {
+		return err
+	}
 
-	} else if isCertExpiring != nil {
-		a.Hostname = fmt.Sprint(alert.Body["details"].(map[string]interface{})["hostname"])
-		a.IP = fmt.Sprint(alert.Body["details"].(map[string]interface{})["ip"])
-		a.Sop = fmt.Sprint(alert.Body["details"].(map[string]interface{})["url"])
-		a.Name = strings.Split(alert.Summary, " on ")[0]
-		a.ClusterName = "N/A"
+	parser := findAlertParser(details)
+	a.Parser = parser.Name
 
-	} else {
-		a.ClusterID = fmt.Sprint(alert.Body["details"].(map[string]interface{})["cluster_id"])
-		a.ClusterName, err = GetClusterName(alert.Service.ID, c)
+	err = extract(ctx, c, parser, alert, details, a)
 
-		// If the service mapped to the current incident is not available (404)
-		if err != nil {
-			a.ClusterName = "N/A"
-		}
-
-		a.Console = fmt.Sprint(alert.Body["details"].(map[string]interface{})["console"])
-		a.Labels = fmt.Sprint(alert.Body["details"].(map[string]interface{})["firing"])
-		a.Sop = fmt.Sprint(alert.Body["details"].(map[string]interface{})["link"])
+	// The configured cluster name resolvers know better than the parser's guess
+	if name := resolveClusterName(ctx, c, alert, details, a.ClusterID); name != "" {
+		a.ClusterName = name
 	}
 
-	// If there's no cluster ID related to the given alert
-	if a.ClusterID == "" {
-		a.ClusterID = "N/A"
-	}
+	return err
+}
 
-	return nil
+// extract runs the parser's extractor, turning a panic in a registered parser into an error
+func extract(ctx context.Context, c PagerDutyClient, parser AlertParser, alert *pagerduty.IncidentAlert, details map[string]interface{}, a *Alert) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("parser panicked: %v", r)
+		}
+	}()
+
+	return parser.Extract(ctx, c, alert, details, a)
 }
 
 // PagerDutyClient implements PagerDutyClientInterface and is used by the pd package to make calls to PagerDuty
@@ -133,7 +156,7 @@ type PagerDutyClient interface {
 // Config is a struct that holds the PagerDuty client used for all the PagerDuty calls, and the config info for
 // teams, silent user, and ignored users
 type Config struct {
-	Client      *pagerduty.Client
+	Client      PagerDutyClient
 	CurrentUser *pagerduty.User
 
 	// List of the users in the Teams
@@ -144,34 +167,47 @@ type Config struct {
 	IgnoredUsers []*pagerduty.User
 }
 
+// NewConfig calls NewConfigWithContext with a background context.
 func NewConfig(token string, teams []string, silentUser string, ignoredUsers []string) (*Config, error) {
+	return NewConfigWithContext(context.Background(), token, teams, silentUser, ignoredUsers)
+}
+
+func NewConfigWithContext(ctx context.Context, token string, teams []string, silentUser string, ignoredUsers []string) (*Config, error) {
+	return NewConfigWithClient(ctx, newClient(token), teams, silentUser, ignoredUsers)
+}
+
+// NewConfigWithClient resolves the current user, teams, team members, silent user and ignored users
+// through the given client. The silent user is optional and left nil if silentUser is empty.
+func NewConfigWithClient(ctx context.Context, client PagerDutyClient, teams []string, silentUser string, ignoredUsers []string) (*Config, error) {
 	var c Config
 	var err error
 
-	c.Client = newClient(token)
+	c.Client = client
 
-	c.CurrentUser, err = c.Client.GetCurrentUserWithContext(context.Background(), pagerduty.GetCurrentUserOptions{})
+	c.CurrentUser, err = c.Client.GetCurrentUserWithContext(ctx, pagerduty.GetCurrentUserOptions{})
 	if err != nil {
 		return &c, fmt.Errorf("pd.NewConfig(): failed to retrieve PagerDuty user: %v", err)
 	}
 
-	c.Teams, err = GetTeams(c.Client, teams)
+	c.Teams, err = GetTeamsWithContext(ctx, c.Client, teams)
 	if err != nil {
 		return &c, fmt.Errorf("pd.NewConfig(): failed to get team(s) `%v`: %v", teams, err)
 	}
 
-	c.TeamsMemberIDs, err = GetTeamMemberIDs(c.Client, c.Teams, pagerduty.ListTeamMembersOptions{Limit: defaultPageLimit, Offset: defaultOffset})
+	c.TeamsMemberIDs, err = GetTeamMemberIDsWithContext(ctx, c.Client, c.Teams, pagerduty.ListTeamMembersOptions{Limit: defaultPageLimit, Offset: defaultOffset})
 	if err != nil {
 		return &c, fmt.Errorf("pd.NewConfig(): failed to get users(s) from teams: %v", err)
 	}
 
-	c.SilentUser, err = GetUser(c.Client, silentUser, pagerduty.GetUserOptions{})
-	if err != nil {
-		return &c, fmt.Errorf("pd.NewConfig(): failed to get silent user: %v", err)
+	if silentUser != "" {
+		c.SilentUser, err = GetUserWithContext(ctx, c.Client, silentUser, pagerduty.GetUserOptions{})
+		if err != nil {
+			return &c, fmt.Errorf("pd.NewConfig(): failed to get silent user: %v", err)
+		}
 	}
 
 	for _, i := range ignoredUsers {
-		user, err := GetUser(c.Client, i, pagerduty.GetUserOptions{})
+		user, err := GetUserWithContext(ctx, c.Client, i, pagerduty.GetUserOptions{})
 		if err != nil {
 			return &c, fmt.Errorf("pd.NewConfig(): failed to get user for ignore list `%v`: %v", i, err)
 		}
@@ -181,8 +217,20 @@ func NewConfig(token string, teams []string, silentUser string, ignoredUsers []s
 	return &c, nil
 }
 
-func newClient(token string) *pagerduty.Client {
-	return pagerduty.NewClient(token)
+func newClient(token string) *Client {
+	return NewClient(token, "")
+}
+
+// NewClient returns a PagerDuty API client, talking to baseURL instead of the public API when it is set.
+// The client passes the delays asked for by throttled responses on to a RetryingClient wrapping it.
+func NewClient(token, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = defaultAPIURL
+	}
+
+	client := pagerduty.NewClient(token, pagerduty.WithAPIEndpoint(baseURL))
+	client.HTTPClient = &retryAfterRecorder{next: client.HTTPClient}
+	return &Client{Client: client, baseURL: baseURL}
 }
 
 func NewListIncidentOptsFromDefaults() pagerduty.ListIncidentsOptions {
@@ -194,74 +242,86 @@ func NewListIncidentOptsFromDefaults() pagerduty.ListIncidentsOptions {
 
 }
 
+// HighAcknowledgedIncidents calls HighAcknowledgedIncidentsWithContext with a background context.
 func HighAcknowledgedIncidents(client PagerDutyClient, users []string) (*pagerduty.ListIncidentsResponse, error) {
-	highAcknowledgedIncidents, err := client.ListIncidentsWithContext(context.TODO(), pagerduty.ListIncidentsOptions{UserIDs: users, Urgencies: []string{"high"}, Statuses: []string{"acknowledged"}})
+	return HighAcknowledgedIncidentsWithContext(context.Background(), client, users)
+}
 
+func HighAcknowledgedIncidentsWithContext(ctx context.Context, client PagerDutyClient, users []string) (*pagerduty.ListIncidentsResponse, error) {
+	highAcknowledgedIncidents, err := client.ListIncidentsWithContext(ctx, pagerduty.ListIncidentsOptions{UserIDs: users, Urgencies: []string{"high"}, Statuses: []string{"acknowledged"}})
 	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	} else {
-		for _, inc := range highAcknowledgedIncidents.Incidents {
-			ack := inc.Acknowledgements
-			id := inc.ID
-			description := inc.Description
-			cluster := inc.HTMLURL
-			service := inc.Service
-			fmt.Printf("\n")
-			fmt.Printf("ACK: %v\n", ack)
-			fmt.Printf("ID: %v\n", id)
-			fmt.Printf("Description: %v\n", description)
-			fmt.Printf("Cluster: %v\n", cluster)
-			fmt.Printf("Service: %v\n", service)
-			fmt.Printf("\n")
-		}
-
+		return nil, fmt.Errorf("pd.HighAcknowledgedIncidents(): failed to get incidents: %v", err)
 	}
-	return highAcknowledgedIncidents, err
 
+	return highAcknowledgedIncidents, nil
 }
 
-func AcknowledgeIncident(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User) ([]pagerduty.Incident, error) {
-	var i []pagerduty.Incident
+// AcknowledgeIncident calls AcknowledgeIncidentWithContext with a background context.
+func AcknowledgeIncident(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User) (*BulkResult, error) {
+	return AcknowledgeIncidentWithContext(context.Background(), client, incidents, user)
+}
 
-	opts := []pagerduty.ManageIncidentsOptions{}
+// AcknowledgeIncidentWithContext acknowledges the incidents and assigns them to the user. The result
+// reports the outcome for each incident; the error lists those it failed on.
+func AcknowledgeIncidentWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User) (*BulkResult, error) {
+	opts, err := incidentOptions(incidents, func(o *pagerduty.ManageIncidentsOptions) {
+		o.Status = "acknowledged"
+		o.Assignments = []pagerduty.Assignee{{Assignee: user.APIObject}}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.AcknowledgeIncident(): %v", err)
+	}
 
-	for _, incident := range incidents {
-		opts = append(opts, pagerduty.ManageIncidentsOptions{
-			ID:     incident.ID,
-			Status: "acknowledged",
-			Assignments: []pagerduty.Assignee{{
-				Assignee: user.APIObject,
-			}},
-		})
+	result := BulkManageIncidentsWithContext(ctx, client, user.Email, opts)
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.AcknowledgeIncident(): failed to acknowledge incident(s): %v", err)
 	}
 
-	for {
-		response, err := client.ManageIncidentsWithContext(context.TODO(), user.Email, opts)
-		if err != nil {
-			return i, fmt.Errorf("pd.AcknowledgeIncident(): failed to acknowledge incident(s) `%v`: %v", incidents, err)
-		}
+	return result, nil
+}
 
-		i = append(i, response.Incidents...)
+// MergeIncidents calls MergeIncidentsWithContext with a background context.
+func MergeIncidents(client PagerDutyClient, target *pagerduty.Incident, sources []pagerduty.Incident, user *pagerduty.User) (*pagerduty.Incident, error) {
+	return MergeIncidentsWithContext(context.Background(), client, target, sources, user)
+}
 
-		if response.More {
-			panic("pd.AcknowledgeIncident(): PagerDuty response indicated more data available")
-		}
+// MergeIncidentsWithContext merges the alerts of the source incidents into the target incident,
+// resolving the source incidents, and returns the updated target.
+func MergeIncidentsWithContext(ctx context.Context, client PagerDutyClient, target *pagerduty.Incident, sources []pagerduty.Incident, user *pagerduty.User) (*pagerduty.Incident, error) {
+	if len(sources) == 0 {
+		return target, nil
+	}
 
-		if !response.More {
-			break
-		}
+	var opts []pagerduty.MergeIncidentsOptions
+	var ids []string
+	for _, source := range sources {
+		opts = append(opts, pagerduty.MergeIncidentsOptions{ID: source.ID, Type: "incident_reference"})
+		ids = append(ids, source.ID)
+	}
 
+	merged, err := client.MergeIncidentsWithContext(ctx, user.Email, target.ID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("pd.MergeIncidents(): failed to merge incident(s) `%v` into `%v`: %v", strings.Join(ids, ", "), target.ID, err)
 	}
 
-	return i, nil
+	return merged, nil
 }
 
+// GetAlerts calls GetAlertsWithContext with a background context.
 func GetAlerts(client PagerDutyClient, id string, opts pagerduty.ListIncidentAlertsOptions) ([]pagerduty.IncidentAlert, error) {
+	return GetAlertsWithContext(context.Background(), client, id, opts)
+}
+
+func GetAlertsWithContext(ctx context.Context, client PagerDutyClient, id string, opts pagerduty.ListIncidentAlertsOptions) ([]pagerduty.IncidentAlert, error) {
 	var a []pagerduty.IncidentAlert
 
+	// Without a limit PagerDuty pages by 25 but reports no limit, so the offset would never advance
+	if opts.Limit == 0 {
+		opts.Limit = defaultPageLimit
+	}
+
 	for {
-		response, err := client.ListIncidentAlertsWithContext(context.TODO(), id, opts)
+		response, err := client.ListIncidentAlertsWithContext(ctx, id, opts)
 		if err != nil {
 			return a, fmt.Errorf("pd.GetAlerts(): failed to get alerts for incident `%v`: %v", id, err)
 		}
@@ -278,10 +338,15 @@ func GetAlerts(client PagerDutyClient, id string, opts pagerduty.ListIncidentAle
 	return a, nil
 }
 
+// GetIncident calls GetIncidentWithContext with a background context.
 func GetIncident(client PagerDutyClient, id string) (*pagerduty.Incident, error) {
+	return GetIncidentWithContext(context.Background(), client, id)
+}
+
+func GetIncidentWithContext(ctx context.Context, client PagerDutyClient, id string) (*pagerduty.Incident, error) {
 	var i *pagerduty.Incident
 
-	i, err := client.GetIncidentWithContext(context.TODO(), id)
+	i, err := client.GetIncidentWithContext(ctx, id)
 	if err != nil {
 		return i, fmt.Errorf("pd.GetIncident(): failed to get incident `%v`: %v", id, err)
 	}
@@ -289,11 +354,21 @@ func GetIncident(client PagerDutyClient, id string) (*pagerduty.Incident, error)
 	return i, nil
 }
 
+// GetIncidents calls GetIncidentsWithContext with a background context.
 func GetIncidents(client PagerDutyClient, opts pagerduty.ListIncidentsOptions) ([]pagerduty.Incident, error) {
+	return GetIncidentsWithContext(context.Background(), client, opts)
+}
+
+func GetIncidentsWithContext(ctx context.Context, client PagerDutyClient, opts pagerduty.ListIncidentsOptions) ([]pagerduty.Incident, error) {
 	var i []pagerduty.Incident
 
+	// Without a limit PagerDuty pages by 25 but reports no limit, so the offset would never advance
+	if opts.Limit == 0 {
+		opts.Limit = defaultPageLimit
+	}
+
 	for {
-		response, err := client.ListIncidentsWithContext(context.TODO(), opts)
+		response, err := client.ListIncidentsWithContext(ctx, opts)
 		if err != nil {
 			return i, fmt.Errorf("pd.GetIncidents(): failed to get incidents : %v", err)
 		}
@@ -310,10 +385,15 @@ func GetIncidents(client PagerDutyClient, opts pagerduty.ListIncidentsOptions) (
 	return i, nil
 }
 
+// GetNotes calls GetNotesWithContext with a background context.
 func GetNotes(client PagerDutyClient, id string) ([]pagerduty.IncidentNote, error) {
+	return GetNotesWithContext(context.Background(), client, id)
+}
+
+func GetNotesWithContext(ctx context.Context, client PagerDutyClient, id string) ([]pagerduty.IncidentNote, error) {
 	var n []pagerduty.IncidentNote
 
-	n, err := client.ListIncidentNotesWithContext(context.TODO(), id)
+	n, err := client.ListIncidentNotesWithContext(ctx, id)
 	if err != nil {
 		return n, fmt.Errorf("pd.GetNotes(): failed to get incident notes `%v`: %v", id, err)
 	}
@@ -321,8 +401,12 @@ func GetNotes(client PagerDutyClient, id string) ([]pagerduty.IncidentNote, erro
 	return n, nil
 }
 
-func GetTeams(client *pagerduty.Client, teams []string) ([]*pagerduty.Team, error) {
-	var ctx = context.Background()
+// GetTeams calls GetTeamsWithContext with a background context.
+func GetTeams(client PagerDutyClient, teams []string) ([]*pagerduty.Team, error) {
+	return GetTeamsWithContext(context.Background(), client, teams)
+}
+
+func GetTeamsWithContext(ctx context.Context, client PagerDutyClient, teams []string) ([]*pagerduty.Team, error) {
 	var t []*pagerduty.Team
 
 	for _, i := range teams {
@@ -336,11 +420,26 @@ func GetTeams(client *pagerduty.Client, teams []string) ([]*pagerduty.Team, erro
 	return t, nil
 }
 
-func GetTeamMemberIDs(client *pagerduty.Client, teams []*pagerduty.Team, opts pagerduty.ListTeamMembersOptions) ([]string, error) {
-	var ctx = context.Background()
+// GetTeamMemberIDs calls GetTeamMemberIDsWithContext with a background context.
+func GetTeamMemberIDs(client PagerDutyClient, teams []*pagerduty.Team, opts pagerduty.ListTeamMembersOptions) ([]string, error) {
+	return GetTeamMemberIDsWithContext(context.Background(), client, teams, opts)
+}
+
+func GetTeamMemberIDsWithContext(ctx context.Context, client PagerDutyClient, teams []*pagerduty.Team, opts pagerduty.ListTeamMembersOptions) ([]string, error) {
 	var u []string
 
+	if opts.Limit == 0 {
+		opts.Limit = defaultPageLimit
+	}
+
 	for _, team := range teams {
+		if team == nil {
+			return u, fmt.Errorf("pd.GetUsers(): team is nil")
+		}
+
+		// Each team is paged through from the caller's starting offset
+		opts := opts
+
 		for {
 			response, err := client.ListMembersWithContext(ctx, team.ID, opts)
 			if err != nil {
@@ -362,8 +461,12 @@ func GetTeamMemberIDs(client *pagerduty.Client, teams []*pagerduty.Team, opts pa
 	return u, nil
 }
 
-func GetUser(client *pagerduty.Client, id string, opts pagerduty.GetUserOptions) (*pagerduty.User, error) {
-	var ctx = context.Background()
+// GetUser calls GetUserWithContext with a background context.
+func GetUser(client PagerDutyClient, id string, opts pagerduty.GetUserOptions) (*pagerduty.User, error) {
+	return GetUserWithContext(context.Background(), client, id, opts)
+}
+
+func GetUserWithContext(ctx context.Context, client PagerDutyClient, id string, opts pagerduty.GetUserOptions) (*pagerduty.User, error) {
 	var u *pagerduty.User
 
 	u, err := client.GetUserWithContext(ctx, id, opts)
@@ -374,52 +477,40 @@ func GetUser(client *pagerduty.Client, id string, opts pagerduty.GetUserOptions)
 	return u, nil
 }
 
-func ReassignIncidents(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, users []*pagerduty.User) ([]pagerduty.Incident, error) {
-	var i []pagerduty.Incident
+// ReassignIncidents calls ReassignIncidentsWithContext with a background context.
+func ReassignIncidents(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, users []*pagerduty.User) (*BulkResult, error) {
+	return ReassignIncidentsWithContext(context.Background(), client, incidents, user, users)
+}
 
+// ReassignIncidentsWithContext assigns the incidents to the given users on behalf of user. The result
+// reports the outcome for each incident; the error lists those it failed on.
+func ReassignIncidentsWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, users []*pagerduty.User) (*BulkResult, error) {
 	a := []pagerduty.Assignee{}
 	for _, user := range users {
 		a = append(a, pagerduty.Assignee{Assignee: user.APIObject})
 	}
 
-	opts := []pagerduty.ManageIncidentsOptions{}
-
-	for _, incident := range incidents {
-		if incident == nil {
-			return i, fmt.Errorf("pd.ReassignIncidents(): incident is nil")
-		}
-		opts = append(opts, pagerduty.ManageIncidentsOptions{
-			ID:          incident.ID,
-			Assignments: a,
-		})
+	opts, err := incidentOptions(incidents, func(o *pagerduty.ManageIncidentsOptions) {
+		o.Assignments = a
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.ReassignIncidents(): %v", err)
 	}
 
-	// This loop is likely unnecessary, as the "More" response is probably not used by PagerDuty here
-	// but I'm including it in case we need to use it in the future, and raising a panic if we receive
-	// a "More" response so we can fix the code
-
-	for {
-		response, err := client.ManageIncidentsWithContext(context.TODO(), user.Email, opts)
-		if err != nil {
-			return i, err
-		}
-
-		if response.More {
-			// If we ever do get a "More" response, we we need to handle it, so panic to call attention to the problem
-			panic("pd.ReassignIncidents(): PagerDuty response indicated more data available")
-		}
-
-		i = append(i, response.Incidents...)
-
-		if !response.More {
-			break
-		}
+	result := BulkManageIncidentsWithContext(ctx, client, user.Email, opts)
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.ReassignIncidents(): failed to reassign incident(s): %v", err)
 	}
 
-	return i, nil
+	return result, nil
 }
 
+// PostNote calls PostNoteWithContext with a background context.
 func PostNote(client PagerDutyClient, id string, user *pagerduty.User, content string) (*pagerduty.IncidentNote, error) {
+	return PostNoteWithContext(context.Background(), client, id, user, content)
+}
+
+func PostNoteWithContext(ctx context.Context, client PagerDutyClient, id string, user *pagerduty.User, content string) (*pagerduty.IncidentNote, error) {
 	var n *pagerduty.IncidentNote
 
 	note := pagerduty.IncidentNote{
@@ -427,7 +518,7 @@ func PostNote(client PagerDutyClient, id string, user *pagerduty.User, content s
 		User:    user.APIObject,
 	}
 
-	n, err := client.CreateIncidentNoteWithContext(context.TODO(), id, note)
+	n, err := client.CreateIncidentNoteWithContext(ctx, id, note)
 	if err != nil {
 		return n, err
 	}
@@ -0,0 +1,146 @@
+package pd
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+// newTestClient returns a fake client with a current user, a silent user and two teams of three members
+func newTestClient() *fake.Client {
+	c := fake.New()
+	for _, id := range []string{"PUSER1", "PUSER2", "PUSER3", "PUSER4", "PUSER5", "PUSER6", "PSILENT"} {
+		c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: id}, Name: id, Email: strings.ToLower(id) + "@example.com"})
+	}
+	c.AddTeam(pagerduty.Team{APIObject: pagerduty.APIObject{ID: "PTEAM1"}, Name: "Team 1"}, "PUSER1", "PUSER2", "PUSER3")
+	c.AddTeam(pagerduty.Team{APIObject: pagerduty.APIObject{ID: "PTEAM2"}, Name: "Team 2"}, "PUSER4", "PUSER5", "PUSER6")
+	return c
+}
+
+func TestNewConfigWithClient(t *testing.T) {
+	c, err := NewConfigWithClient(context.Background(), newTestClient(), []string{"PTEAM1", "PTEAM2"}, "PSILENT", []string{"PUSER2"})
+	if err != nil {
+		t.Fatalf("NewConfigWithClient() error = %v", err)
+	}
+
+	if c.CurrentUser.ID != "PUSER1" {
+		t.Errorf("CurrentUser = %v, want PUSER1", c.CurrentUser.ID)
+	}
+	if len(c.Teams) != 2 {
+		t.Errorf("got %v teams, want 2", len(c.Teams))
+	}
+	if want := []string{"PUSER1", "PUSER2", "PUSER3", "PUSER4", "PUSER5", "PUSER6"}; !slices.Equal(c.TeamsMemberIDs, want) {
+		t.Errorf("TeamsMemberIDs = %v, want %v", c.TeamsMemberIDs, want)
+	}
+	if c.SilentUser == nil || c.SilentUser.ID != "PSILENT" {
+		t.Errorf("SilentUser = %v, want PSILENT", c.SilentUser)
+	}
+	if len(c.IgnoredUsers) != 1 || c.IgnoredUsers[0].ID != "PUSER2" {
+		t.Errorf("IgnoredUsers = %v, want [PUSER2]", c.IgnoredUsers)
+	}
+}
+
+func TestNewConfigWithClientErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		client       *fake.Client
+		teams        []string
+		silentUser   string
+		ignoredUsers []string
+		want         string
+	}{
+		{
+			name:   "no current user",
+			client: fake.New(),
+			teams:  []string{"PTEAM1"},
+			want:   "failed to retrieve PagerDuty user",
+		},
+		{
+			name:   "unknown team",
+			client: newTestClient(),
+			teams:  []string{"PTEAM1", "PNOPE"},
+			want:   "failed to find PagerDuty team `PNOPE`",
+		},
+		{
+			name:       "unknown silent user",
+			client:     newTestClient(),
+			teams:      []string{"PTEAM1"},
+			silentUser: "PNOPE",
+			want:       "failed to get silent user",
+		},
+		{
+			name:         "unknown ignored user",
+			client:       newTestClient(),
+			teams:        []string{"PTEAM1"},
+			ignoredUsers: []string{"PUSER2", "PNOPE"},
+			want:         "failed to get user for ignore list `PNOPE`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfigWithClient(context.Background(), tt.client, tt.teams, tt.silentUser, tt.ignoredUsers)
+			if err == nil {
+				t.Fatalf("NewConfigWithClient() error = nil, want %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewConfigWithClient() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTeamsUnknownTeam(t *testing.T) {
+	teams, err := GetTeams(newTestClient(), []string{"PTEAM1", "PNOPE"})
+	if err == nil || !strings.Contains(err.Error(), "status code 404") {
+		t.Fatalf("GetTeams() error = %v, want a 404", err)
+	}
+	if len(teams) != 1 || teams[0].ID != "PTEAM1" {
+		t.Errorf("GetTeams() = %v, want the teams found before the error", teams)
+	}
+}
+
+func TestGetTeamMemberIDs(t *testing.T) {
+	client := newTestClient()
+	teams, err := GetTeams(client, []string{"PTEAM1", "PTEAM2"})
+	if err != nil {
+		t.Fatalf("GetTeams() error = %v", err)
+	}
+
+	t.Run("pages through each team from the start", func(t *testing.T) {
+		// Two members per page, so each team takes two pages and the offset must be reset between them
+		ids, err := GetTeamMemberIDs(client, teams, pagerduty.ListTeamMembersOptions{Limit: 2})
+		if err != nil {
+			t.Fatalf("GetTeamMemberIDs() error = %v", err)
+		}
+		if want := []string{"PUSER1", "PUSER2", "PUSER3", "PUSER4", "PUSER5", "PUSER6"}; !slices.Equal(ids, want) {
+			t.Errorf("GetTeamMemberIDs() = %v, want %v", ids, want)
+		}
+	})
+
+	t.Run("nil team", func(t *testing.T) {
+		_, err := GetTeamMemberIDs(client, []*pagerduty.Team{teams[0], nil}, pagerduty.ListTeamMembersOptions{})
+		if err == nil || !strings.Contains(err.Error(), "team is nil") {
+			t.Errorf("GetTeamMemberIDs() error = %v, want team is nil", err)
+		}
+	})
+
+	t.Run("team removed since it was resolved", func(t *testing.T) {
+		gone := &pagerduty.Team{APIObject: pagerduty.APIObject{ID: "PGONE"}}
+		_, err := GetTeamMemberIDs(client, []*pagerduty.Team{gone}, pagerduty.ListTeamMembersOptions{})
+		if err == nil || !strings.Contains(err.Error(), "PGONE") {
+			t.Errorf("GetTeamMemberIDs() error = %v, want one naming PGONE", err)
+		}
+	})
+}
+
+func TestGetUserUnknownUser(t *testing.T) {
+	_, err := GetUser(newTestClient(), "PNOPE", pagerduty.GetUserOptions{})
+	if err == nil || !strings.Contains(err.Error(), "failed to find PagerDuty user `PNOPE`") {
+		t.Errorf("GetUser() error = %v, want failed to find PagerDuty user `PNOPE`", err)
+	}
+}
@@ -0,0 +1,217 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/PagerDuty/go-pagerduty"
+	utils "github.com/aliceh/alertops/pkg/utils"
+)
+
+// Names of the built-in alert parsers
+const (
+	ParserCHGM         = "chgm"
+	ParserCertificate  = "certificate"
+	ParserAlertmanager = "alertmanager"
+)
+
+// AlertParser turns the details of a PagerDuty alert of a given shape into an Alert.
+// Match is called with the alert body details and reports whether the parser handles the alert,
+// Extract fills in the alert fields that depend on its shape.
+type AlertParser struct {
+	Name    string
+	Match   func(details map[string]interface{}) bool
+	Extract func(ctx context.Context, c PagerDutyClient, alert *pagerduty.IncidentAlert, details map[string]interface{}, a *Alert) error
+
+	// mapping is set on the parsers of alert mappings, which a new configuration replaces
+	mapping bool
+}
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = []AlertParser{chgmParser, certificateParser}
+
+	// fallbackParser handles every alert none of the registered parsers matched
+	fallbackParser = alertmanagerParser
+)
+
+// RegisterAlertParser adds a parser to the registry. Parsers registered later take precedence
+// over earlier ones and over the built-in parsers, so a team can override how a shape is parsed.
+func RegisterAlertParser(p AlertParser) error {
+	if p.Name == "" || p.Match == nil || p.Extract == nil {
+		return fmt.Errorf("pd.RegisterAlertParser(): a parser needs a name, a match predicate and an extractor")
+	}
+
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+
+	if registered(parsers, p.Name) {
+		return fmt.Errorf("pd.RegisterAlertParser(): parser `%v` is already registered", p.Name)
+	}
+
+	parsers = append([]AlertParser{p}, parsers...)
+
+	return nil
+}
+
+// replaceMappingParsers registers the parsers of alert mappings in place of those of an earlier
+// configuration, leaving the parsers registered in code as they are
+func replaceMappingParsers(mapped []AlertParser) error {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+
+	kept := slices.DeleteFunc(slices.Clone(parsers), func(p AlertParser) bool { return p.mapping })
+	for _, p := range mapped {
+		if registered(kept, p.Name) {
+			return fmt.Errorf("pd.RegisterAlertMappings(): parser `%v` is already registered", p.Name)
+		}
+		p.mapping = true
+		kept = append([]AlertParser{p}, kept...)
+	}
+
+	parsers = kept
+
+	return nil
+}
+
+// registered reports whether the name is taken by one of the parsers or the fallback parser
+func registered(parsers []AlertParser, name string) bool {
+	return name == fallbackParser.Name || slices.ContainsFunc(parsers, func(p AlertParser) bool { return p.Name == name })
+}
+
+// AlertParsers returns the names of the registered parsers in the order they are tried.
+func AlertParsers() []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	var names []string
+	for _, p := range parsers {
+		names = append(names, p.Name)
+	}
+
+	return append(names, fallbackParser.Name)
+}
+
+// findAlertParser returns the first registered parser matching the details, or the fallback parser
+func findAlertParser(details map[string]interface{}) AlertParser {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	for _, p := range parsers {
+		if p.Match(details) {
+			return p
+		}
+	}
+
+	return fallbackParser
+}
+
+// alertDetails returns the details of the alert body, or a FieldError if it has none
+func alertDetails(alert *pagerduty.IncidentAlert) (map[string]interface{}, error) {
+	v, ok := alert.Body["details"]
+	if !ok || v == nil {
+		return nil, &FieldError{Field: "details", Err: ErrFieldMissing}
+	}
+
+	details, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, &FieldError{Field: "details", Err: fmt.Errorf("%w: %T", ErrFieldMistyped, v)}
+	}
+
+	return details, nil
+}
+
+// hasDetail returns a match predicate checking the details contain the given key
+func hasDetail(key string) func(details map[string]interface{}) bool {
+	return func(details map[string]interface{}) bool {
+		return details[key] != nil
+	}
+}
+
+// chgmParser handles 'Cluster has gone missing' alerts reported by Dead Man's Snitch
+var chgmParser = AlertParser{
+	Name:  ParserCHGM,
+	Match: hasDetail("notes"),
+	Extract: func(ctx context.Context, c PagerDutyClient, alert *pagerduty.IncidentAlert, details map[string]interface{}, a *Alert) error {
+		r := &detailReader{details: details}
+
+		if notes := strings.Split(r.string("notes"), "\n"); notes[0] != "" {
+			if strings.HasPrefix(notes[0], "cluster_id: ") {
+				a.ClusterID = strings.TrimPrefix(notes[0], "cluster_id: ")
+			} else {
+				r.fail("notes.cluster_id", ErrFieldMissing)
+			}
+			if len(notes) > 1 && strings.HasPrefix(notes[1], "runbook: ") {
+				a.Sop = strings.TrimPrefix(notes[1], "runbook: ")
+			} else {
+				r.fail("notes.runbook", ErrFieldMissing)
+			}
+		}
+
+		if name := r.string("name"); name != "" {
+			a.ClusterName = strings.Split(name, ".")[0]
+		}
+
+		if lastCheckIn := r.string("last healthy check-in"); lastCheckIn != "" {
+			formatted, err := utils.FormatTimestamp(lastCheckIn)
+			if err != nil {
+				r.fail("last healthy check-in", fmt.Errorf("%w: %v", ErrFieldMistyped, err))
+			}
+			a.LastCheckIn = formatted
+		}
+
+		a.Token = r.optional("token")
+		a.Tags = r.optional("tags")
+
+		return r.err()
+	},
+}
+
+// certificateParser handles 'Certificate is expiring' alerts
+var certificateParser = AlertParser{
+	Name:  ParserCertificate,
+	Match: hasDetail("hostname"),
+	Extract: func(ctx context.Context, c PagerDutyClient, alert *pagerduty.IncidentAlert, details map[string]interface{}, a *Alert) error {
+		r := &detailReader{details: details}
+
+		a.Hostname = r.string("hostname")
+		a.IP = r.optional("ip")
+		a.Sop = r.string("url")
+		a.Name = strings.Split(alert.Summary, " on ")[0]
+		a.ClusterName = "N/A"
+
+		return r.err()
+	},
+}
+
+// alertmanagerParser handles the default cluster_id/console/firing layout sent by Alertmanager
+var alertmanagerParser = AlertParser{
+	Name:  ParserAlertmanager,
+	Match: func(details map[string]interface{}) bool { return true },
+	Extract: func(ctx context.Context, c PagerDutyClient, alert *pagerduty.IncidentAlert, details map[string]interface{}, a *Alert) (err error) {
+		r := &detailReader{details: details}
+
+		// Every alert no other parser matched lands here, so a missing cluster ID is not an error
+		a.ClusterID = r.optional("cluster_id")
+		a.ClusterName, err = GetClusterNameWithContext(ctx, alert.Service.ID, c)
+
+		// If the service mapped to the current incident is not available (404)
+		if err != nil {
+			a.ClusterName = "N/A"
+		}
+
+		a.Console = r.optional("console")
+		a.Labels = r.optional("firing")
+		a.AlertLabels, a.Annotations = parseFiring(a.Labels)
+
+		a.Sop = r.optional("link")
+		if a.Sop == "" {
+			a.Sop = a.Annotations["runbook_url"]
+		}
+
+		return r.err()
+	},
+}
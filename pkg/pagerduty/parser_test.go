@@ -0,0 +1,163 @@
+package pd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+// withParser puts the parser first in the registry for the duration of the test. Parsers registered
+// in code cannot be unregistered, unlike alert mappings.
+func withParser(t *testing.T, p AlertParser) {
+	t.Helper()
+
+	parsersMu.Lock()
+	saved := parsers
+	parsers = append([]AlertParser{p}, parsers...)
+	parsersMu.Unlock()
+
+	t.Cleanup(func() {
+		parsersMu.Lock()
+		parsers = saved
+		parsersMu.Unlock()
+	})
+}
+
+func newAlert(details interface{}) *pagerduty.IncidentAlert {
+	return &pagerduty.IncidentAlert{
+		APIObject: pagerduty.APIObject{ID: "PALERT1", Summary: "ClusterHasGoneMissing prod-east-1"},
+		Incident:  pagerduty.APIReference{ID: "PINC001"},
+		Status:    "triggered",
+		Body:      map[string]interface{}{"details": details},
+	}
+}
+
+func TestParseAlertDataPartial(t *testing.T) {
+	withParser(t, AlertParser{
+		Name:  "panicky",
+		Match: hasDetail("panic"),
+		Extract: func(ctx context.Context, c PagerDutyClient, alert *pagerduty.IncidentAlert, details map[string]interface{}, a *Alert) error {
+			a.ClusterID = "c-123"
+			panic("boom")
+		},
+	})
+
+	tests := []struct {
+		name        string
+		details     interface{}
+		wantParser  string
+		wantErr     string
+		wantField   error
+		wantCluster string
+		wantName    string
+	}{
+		{
+			name:        "nil details",
+			details:     nil,
+			wantErr:     "field `details`: missing",
+			wantField:   ErrFieldMissing,
+			wantCluster: "N/A",
+		},
+		{
+			name:        "details of the wrong type",
+			details:     "cluster_id: c-123",
+			wantErr:     "field `details`: unexpected type: string",
+			wantField:   ErrFieldMistyped,
+			wantCluster: "N/A",
+		},
+		{
+			name:        "detail of the wrong type",
+			details:     map[string]interface{}{"notes": 42, "name": "prod-east-1.example.com"},
+			wantParser:  ParserCHGM,
+			wantErr:     "field `notes`: unexpected type: int",
+			wantField:   ErrFieldMistyped,
+			wantCluster: "N/A",
+			wantName:    "prod-east-1",
+		},
+		{
+			name:        "parser panics",
+			details:     map[string]interface{}{"panic": true},
+			wantParser:  "panicky",
+			wantErr:     "parser panicked: boom",
+			wantCluster: "c-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a Alert
+			err := a.ParseAlertDataWithContext(context.Background(), fake.New(), newAlert(tt.details))
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("error = %v, want a *ParseError", err)
+			}
+			if parseErr.AlertID != "PALERT1" || parseErr.Parser != tt.wantParser || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want alert PALERT1, parser %q and %q", err, tt.wantParser, tt.wantErr)
+			}
+			if tt.wantField != nil && !errors.Is(err, tt.wantField) {
+				t.Errorf("error = %v, want %v", err, tt.wantField)
+			}
+
+			// The fields that could be read are still filled in
+			if !a.Partial || a.ParseError == "" {
+				t.Errorf("Partial = %v, ParseError = %q, want the alert marked as partial", a.Partial, a.ParseError)
+			}
+			if a.AlertID != "PALERT1" || a.IncidentID != "PINC001" || a.Name != "ClusterHasGoneMissing prod-east-1" || a.Status != "triggered" {
+				t.Errorf("alert = %+v, want the fields of the PagerDuty alert", a)
+			}
+			if a.ClusterID != tt.wantCluster || a.ClusterName != tt.wantName {
+				t.Errorf("ClusterID = %v, ClusterName = %v, want %v, %v", a.ClusterID, a.ClusterName, tt.wantCluster, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestExtractRecoversPanic(t *testing.T) {
+	parser := AlertParser{
+		Name: "panicky",
+		Extract: func(ctx context.Context, c PagerDutyClient, alert *pagerduty.IncidentAlert, details map[string]interface{}, a *Alert) error {
+			var labels map[string]string
+			labels["severity"] = "critical"
+			return nil
+		},
+	}
+
+	err := extract(context.Background(), fake.New(), parser, newAlert(nil), nil, &Alert{})
+	if err == nil || !strings.HasPrefix(err.Error(), "parser panicked: ") {
+		t.Errorf("extract() error = %v, want the panic as an error", err)
+	}
+}
+
+func TestParseAlertDataFallback(t *testing.T) {
+	firing := "Labels:\n - alertname = KubeAPIDown\n - severity = critical\nAnnotations:\n - runbook_url = https://example.com/KubeAPIDown.md\n"
+
+	tests := []struct {
+		name        string
+		details     map[string]interface{}
+		wantCluster string
+	}{
+		{name: "with a cluster ID", details: map[string]interface{}{"cluster_id": "c-123", "firing": firing}, wantCluster: "c-123"},
+		{name: "without a cluster ID", details: map[string]interface{}{"firing": firing}, wantCluster: UnknownCluster},
+		{name: "not an Alertmanager alert", details: map[string]interface{}{"Trigger": "CPU above 90%"}, wantCluster: UnknownCluster},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a Alert
+			if err := a.ParseAlertDataWithContext(context.Background(), fake.New(), newAlert(tt.details)); err != nil {
+				t.Fatalf("ParseAlertData() error = %v", err)
+			}
+			if a.Parser != ParserAlertmanager || a.Partial || a.ParseError != "" {
+				t.Errorf("parser %v, Partial = %v, ParseError = %q, want a complete alertmanager alert", a.Parser, a.Partial, a.ParseError)
+			}
+			if a.ClusterID != tt.wantCluster {
+				t.Errorf("ClusterID = %v, want %v", a.ClusterID, tt.wantCluster)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package pd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// Defaults of RetryOptions
+const (
+	DefaultMaxRetries = 4
+	DefaultRetryDelay = 500 * time.Millisecond
+	DefaultMaxDelay   = 30 * time.Second
+)
+
+// RetryOptions tunes the backoff of a RetryingClient.
+type RetryOptions struct {
+	// MaxRetries is how many times a call is retried before giving up, 0 to never retry it and a
+	// negative value for DefaultMaxRetries
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, doubled on every following one up to MaxDelay
+	BaseDelay time.Duration
+	// MaxDelay also caps the delays PagerDuty asks for
+	MaxDelay time.Duration
+}
+
+// RetryStats counts the calls of one method made through a RetryingClient.
+type RetryStats struct {
+	Calls   uint64 `json:"calls" yaml:"calls"`
+	Retries uint64 `json:"retries" yaml:"retries"`
+	// GaveUp counts the calls that still failed with a retryable error after the last retry
+	GaveUp uint64 `json:"gave_up" yaml:"gave_up"`
+}
+
+// RetryingClient wraps a PagerDutyClient to retry the calls PagerDuty throttled (HTTP 429) or failed
+// to serve (HTTP 5xx), with exponential backoff and jitter. A delay asked for by PagerDuty through the
+// Retry-After or ratelimit-reset headers is honoured instead, up to MaxDelay, if the wrapped client was
+// built with NewClient. Calls changing incidents are only retried when throttled, as PagerDuty rejects those
+// before acting on them, whereas a 5xx leaves it unknown whether the change was made.
+type RetryingClient struct {
+	PagerDutyClient
+
+	opts RetryOptions
+
+	// Logf, if set, is called before each retry
+	Logf func(format string, args ...any)
+
+	mu    sync.Mutex
+	stats map[string]*RetryStats
+}
+
+// NewRetryingClient wraps client to retry its failed calls, using the defaults for the zero delays and
+// a negative MaxRetries.
+func NewRetryingClient(client PagerDutyClient, opts RetryOptions) *RetryingClient {
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = DefaultRetryDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = DefaultMaxDelay
+	}
+
+	return &RetryingClient{PagerDutyClient: client, opts: opts, stats: map[string]*RetryStats{}}
+}
+
+func (c *RetryingClient) CreateIncidentNoteWithContext(ctx context.Context, id string, note pagerduty.IncidentNote) (*pagerduty.IncidentNote, error) {
+	return retry(ctx, c, "CreateIncidentNote", false, func(ctx context.Context) (*pagerduty.IncidentNote, error) {
+		return c.PagerDutyClient.CreateIncidentNoteWithContext(ctx, id, note)
+	})
+}
+
+func (c *RetryingClient) GetCurrentUserWithContext(ctx context.Context, opts pagerduty.GetCurrentUserOptions) (*pagerduty.User, error) {
+	return retry(ctx, c, "GetCurrentUser", true, func(ctx context.Context) (*pagerduty.User, error) {
+		return c.PagerDutyClient.GetCurrentUserWithContext(ctx, opts)
+	})
+}
+
+func (c *RetryingClient) GetIncidentWithContext(ctx context.Context, id string) (*pagerduty.Incident, error) {
+	return retry(ctx, c, "GetIncident", true, func(ctx context.Context) (*pagerduty.Incident, error) {
+		return c.PagerDutyClient.GetIncidentWithContext(ctx, id)
+	})
+}
+
+func (c *RetryingClient) GetServiceWithContext(ctx context.Context, serviceID string, opts *pagerduty.GetServiceOptions) (*pagerduty.Service, error) {
+	return retry(ctx, c, "GetService", true, func(ctx context.Context) (*pagerduty.Service, error) {
+		return c.PagerDutyClient.GetServiceWithContext(ctx, serviceID, opts)
+	})
+}
+
+func (c *RetryingClient) GetTeamWithContext(ctx context.Context, id string) (*pagerduty.Team, error) {
+	return retry(ctx, c, "GetTeam", true, func(ctx context.Context) (*pagerduty.Team, error) {
+		return c.PagerDutyClient.GetTeamWithContext(ctx, id)
+	})
+}
+
+func (c *RetryingClient) ListMembersWithContext(ctx context.Context, id string, opts pagerduty.ListTeamMembersOptions) (*pagerduty.ListTeamMembersResponse, error) {
+	return retry(ctx, c, "ListMembers", true, func(ctx context.Context) (*pagerduty.ListTeamMembersResponse, error) {
+		return c.PagerDutyClient.ListMembersWithContext(ctx, id, opts)
+	})
+}
+
+func (c *RetryingClient) GetUserWithContext(ctx context.Context, id string, opts pagerduty.GetUserOptions) (*pagerduty.User, error) {
+	return retry(ctx, c, "GetUser", true, func(ctx context.Context) (*pagerduty.User, error) {
+		return c.PagerDutyClient.GetUserWithContext(ctx, id, opts)
+	})
+}
+
+func (c *RetryingClient) ListIncidentAlertsWithContext(ctx context.Context, id string, opts pagerduty.ListIncidentAlertsOptions) (*pagerduty.ListAlertsResponse, error) {
+	return retry(ctx, c, "ListIncidentAlerts", true, func(ctx context.Context) (*pagerduty.ListAlertsResponse, error) {
+		return c.PagerDutyClient.ListIncidentAlertsWithContext(ctx, id, opts)
+	})
+}
+
+func (c *RetryingClient) ListIncidentsWithContext(ctx context.Context, opts pagerduty.ListIncidentsOptions) (*pagerduty.ListIncidentsResponse, error) {
+	return retry(ctx, c, "ListIncidents", true, func(ctx context.Context) (*pagerduty.ListIncidentsResponse, error) {
+		return c.PagerDutyClient.ListIncidentsWithContext(ctx, opts)
+	})
+}
+
+func (c *RetryingClient) ListIncidentLogEntriesWithContext(ctx context.Context, id string, opts pagerduty.ListIncidentLogEntriesOptions) (*pagerduty.ListIncidentLogEntriesResponse, error) {
+	return retry(ctx, c, "ListIncidentLogEntries", true, func(ctx context.Context) (*pagerduty.ListIncidentLogEntriesResponse, error) {
+		return c.PagerDutyClient.ListIncidentLogEntriesWithContext(ctx, id, opts)
+	})
+}
+
+func (c *RetryingClient) ListIncidentNotesWithContext(ctx context.Context, id string) ([]pagerduty.IncidentNote, error) {
+	return retry(ctx, c, "ListIncidentNotes", true, func(ctx context.Context) ([]pagerduty.IncidentNote, error) {
+		return c.PagerDutyClient.ListIncidentNotesWithContext(ctx, id)
+	})
+}
+
+func (c *RetryingClient) ListOnCallsWithContext(ctx context.Context, opts pagerduty.ListOnCallOptions) (*pagerduty.ListOnCallsResponse, error) {
+	return retry(ctx, c, "ListOnCalls", true, func(ctx context.Context) (*pagerduty.ListOnCallsResponse, error) {
+		return c.PagerDutyClient.ListOnCallsWithContext(ctx, opts)
+	})
+}
+
+func (c *RetryingClient) ListPrioritiesWithContext(ctx context.Context, opts pagerduty.ListPrioritiesOptions) (*pagerduty.ListPrioritiesResponse, error) {
+	return retry(ctx, c, "ListPriorities", true, func(ctx context.Context) (*pagerduty.ListPrioritiesResponse, error) {
+		return c.PagerDutyClient.ListPrioritiesWithContext(ctx, opts)
+	})
+}
+
+func (c *RetryingClient) ManageIncidentsWithContext(ctx context.Context, email string, opts []pagerduty.ManageIncidentsOptions) (*pagerduty.ListIncidentsResponse, error) {
+	return retry(ctx, c, "ManageIncidents", false, func(ctx context.Context) (*pagerduty.ListIncidentsResponse, error) {
+		return c.PagerDutyClient.ManageIncidentsWithContext(ctx, email, opts)
+	})
+}
+
+func (c *RetryingClient) MergeIncidentsWithContext(ctx context.Context, from, id string, sourceIncidents []pagerduty.MergeIncidentsOptions) (*pagerduty.Incident, error) {
+	return retry(ctx, c, "MergeIncidents", false, func(ctx context.Context) (*pagerduty.Incident, error) {
+		return c.PagerDutyClient.MergeIncidentsWithContext(ctx, from, id, sourceIncidents)
+	})
+}
+
+func (c *RetryingClient) SnoozeIncidentWithContext(ctx context.Context, from, id string, duration uint) (*pagerduty.Incident, error) {
+	return retry(ctx, c, "SnoozeIncident", false, func(ctx context.Context) (*pagerduty.Incident, error) {
+		return c.PagerDutyClient.SnoozeIncidentWithContext(ctx, from, id, duration)
+	})
+}
+
+func (c *RetryingClient) UpdateIncidentUrgencyWithContext(ctx context.Context, from, id, urgency string) (*pagerduty.Incident, error) {
+	return retry(ctx, c, "UpdateIncidentUrgency", false, func(ctx context.Context) (*pagerduty.Incident, error) {
+		return c.PagerDutyClient.UpdateIncidentUrgencyWithContext(ctx, from, id, urgency)
+	})
+}
+
+// Stats returns the retry statistics of each method called so far.
+func (c *RetryingClient) Stats() map[string]RetryStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := map[string]RetryStats{}
+	for method, s := range c.stats {
+		stats[method] = *s
+	}
+	return stats
+}
+
+// FormatRetryStats formats retry statistics as one line per method.
+func FormatRetryStats(stats map[string]RetryStats) string {
+	var methods []string
+	for method := range stats {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	var s string
+	for _, method := range methods {
+		st := stats[method]
+		s += fmt.Sprintf("retries %-18v %v calls, %v retries, %v gave up\n", method, st.Calls, st.Retries, st.GaveUp)
+	}
+	return s
+}
+
+// retry calls call until it succeeds, fails with an error that is not worth retrying, runs out of
+// retries or ctx is done
+func retry[T any](ctx context.Context, c *RetryingClient, method string, idempotent bool, call func(ctx context.Context) (T, error)) (T, error) {
+	c.count(method, func(s *RetryStats) { s.Calls++ })
+
+	for attempt := 0; ; attempt++ {
+		hint := &retryHint{}
+		v, err := call(context.WithValue(ctx, retryHintKey{}, hint))
+		if err == nil {
+			return v, nil
+		}
+
+		status, retryable := retryableStatus(err, idempotent)
+		if !retryable || ctx.Err() != nil {
+			return v, err
+		}
+		if attempt >= c.opts.MaxRetries {
+			c.count(method, func(s *RetryStats) { s.GaveUp++ })
+			return v, err
+		}
+
+		wait := c.backoff(attempt)
+		if hint.after > 0 {
+			wait = min(hint.after, c.opts.MaxDelay)
+		}
+
+		c.count(method, func(s *RetryStats) { s.Retries++ })
+		if c.Logf != nil {
+			c.Logf("%v: HTTP %v, retry %v/%v in %v", method, status, attempt+1, c.opts.MaxRetries, wait.Round(time.Millisecond))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return v, err
+		}
+	}
+}
+
+// retryableStatus returns the HTTP status of err and whether the call is worth retrying
+func retryableStatus(err error, idempotent bool) (int, bool) {
+	var apiErr pagerduty.APIError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+
+	if apiErr.RateLimited() {
+		return apiErr.StatusCode, true
+	}
+	return apiErr.StatusCode, idempotent && apiErr.Temporary()
+}
+
+// backoff returns the delay before the given retry: an exponential delay with full jitter over its
+// upper half, so concurrent callers throttled together do not retry together
+func (c *RetryingClient) backoff(attempt int) time.Duration {
+	delay := c.opts.MaxDelay
+	if attempt < 32 {
+		delay = min(c.opts.BaseDelay<<attempt, c.opts.MaxDelay)
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (c *RetryingClient) count(method string, update func(s *RetryStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.stats[method]
+	if !ok {
+		s = &RetryStats{}
+		c.stats[method] = s
+	}
+	update(s)
+}
+
+// retryHint carries the delay PagerDuty asked for from the HTTP client to RetryingClient, through the
+// request context, as pagerduty.APIError does not keep the response headers
+type retryHint struct {
+	after time.Duration
+}
+
+type retryHintKey struct{}
+
+// retryAfterRecorder is the HTTP client of the clients built by NewClient
+type retryAfterRecorder struct {
+	next pagerduty.HTTPClient
+}
+
+func (r *retryAfterRecorder) Do(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.Do(req)
+	if err != nil || resp.StatusCode < 429 {
+		return resp, err
+	}
+
+	hint, ok := req.Context().Value(retryHintKey{}).(*retryHint)
+	if !ok {
+		return resp, err
+	}
+
+	if after := retryAfter(resp.Header, time.Now()); after > 0 {
+		hint.after = after
+	}
+
+	return resp, err
+}
+
+// retryAfter reads the delay asked for by the Retry-After header, in seconds or as an HTTP date, or
+// by PagerDuty's ratelimit-reset header, in seconds
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	var after time.Duration
+
+	if v := h.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			after = time.Duration(seconds) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			after = t.Sub(now)
+		}
+	}
+
+	if seconds, err := strconv.Atoi(h.Get("ratelimit-reset")); err == nil {
+		after = max(after, time.Duration(seconds)*time.Second)
+	}
+
+	return after
+}
@@ -0,0 +1,167 @@
+package pd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// failingClient fails its first calls with the given HTTP statuses, then succeeds
+type failingClient struct {
+	PagerDutyClient
+
+	statuses []int
+	calls    int
+}
+
+func (c *failingClient) fail() error {
+	c.calls++
+	if c.calls <= len(c.statuses) {
+		return fmt.Errorf("call %v: %w", c.calls, pagerduty.APIError{StatusCode: c.statuses[c.calls-1]})
+	}
+	return nil
+}
+
+func (c *failingClient) CreateIncidentNoteWithContext(ctx context.Context, id string, note pagerduty.IncidentNote) (*pagerduty.IncidentNote, error) {
+	if err := c.fail(); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (c *failingClient) GetIncidentWithContext(ctx context.Context, id string) (*pagerduty.Incident, error) {
+	if err := c.fail(); err != nil {
+		return nil, err
+	}
+	return &pagerduty.Incident{APIObject: pagerduty.APIObject{ID: id}}, nil
+}
+
+func TestRetryingClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		// idempotent calls GetIncident, otherwise CreateIncidentNote is called
+		idempotent bool
+		wantErr    bool
+		wantCalls  int
+	}{
+		{name: "non-idempotent call retried when throttled", statuses: []int{429, 429}, maxRetries: 4, wantCalls: 3},
+		{name: "non-idempotent call not retried on 5xx", statuses: []int{503}, maxRetries: 4, wantErr: true, wantCalls: 1},
+		{name: "idempotent call retried on 5xx", statuses: []int{500, 502, 503}, maxRetries: 4, idempotent: true, wantCalls: 4},
+		{name: "client errors are not retried", statuses: []int{404}, maxRetries: 4, idempotent: true, wantErr: true, wantCalls: 1},
+		{name: "gives up after the last retry", statuses: []int{429, 429, 429}, maxRetries: 2, wantErr: true, wantCalls: 3},
+		{name: "zero retries", statuses: []int{429}, maxRetries: 0, wantErr: true, wantCalls: 1},
+		{name: "negative retries use the default", statuses: []int{429, 429, 429, 429}, maxRetries: -1, wantCalls: DefaultMaxRetries + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &failingClient{statuses: tt.statuses}
+			c := NewRetryingClient(stub, RetryOptions{MaxRetries: tt.maxRetries, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+			var err error
+			method := "CreateIncidentNote"
+			if tt.idempotent {
+				method = "GetIncident"
+				_, err = c.GetIncidentWithContext(context.Background(), "PINC001")
+			} else {
+				_, err = c.CreateIncidentNoteWithContext(context.Background(), "PINC001", pagerduty.IncidentNote{Content: "note"})
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if stub.calls != tt.wantCalls {
+				t.Errorf("got %v call(s), want %v", stub.calls, tt.wantCalls)
+			}
+
+			stats := c.Stats()[method]
+			if stats.Calls != 1 || stats.Retries != uint64(tt.wantCalls-1) {
+				t.Errorf("stats = %+v, want 1 call and %v retries", stats, tt.wantCalls-1)
+			}
+		})
+	}
+}
+
+func TestRetryingClientCancelled(t *testing.T) {
+	stub := &failingClient{statuses: []int{429, 429}}
+	c := NewRetryingClient(stub, RetryOptions{MaxRetries: 4, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Logf = func(string, ...any) { cancel() }
+
+	_, err := c.GetIncidentWithContext(ctx, "PINC001")
+	var apiErr pagerduty.APIError
+	if !errors.As(err, &apiErr) || !apiErr.RateLimited() {
+		t.Errorf("error = %v, want the throttled error", err)
+	}
+	if stub.calls != 1 {
+		t.Errorf("got %v call(s), want 1", stub.calls)
+	}
+}
+
+// TestRetryAfter checks the delay PagerDuty asks for is used instead of the backoff, up to MaxDelay.
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		maxDelay time.Duration
+		want     string
+		// wait for the retry rather than stopping once its delay is logged
+		wait bool
+	}{
+		{name: "retry-after seconds", header: "Retry-After", value: "10", maxDelay: time.Minute, want: "in 10s"},
+		{name: "ratelimit-reset seconds", header: "ratelimit-reset", value: "20", maxDelay: time.Minute, want: "in 20s"},
+		{name: "capped at the max delay", header: "Retry-After", value: "3600", maxDelay: 20 * time.Millisecond, want: "in 20ms", wait: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				if calls == 1 {
+					w.Header().Set(tt.header, tt.value)
+					w.WriteHeader(http.StatusTooManyRequests)
+					fmt.Fprint(w, `{"error":{"code":2020,"message":"Rate Limit Exceeded"}}`)
+					return
+				}
+				fmt.Fprint(w, `{"incident":{"id":"PINC001"}}`)
+			}))
+			defer srv.Close()
+
+			c := NewRetryingClient(NewClient("test-token", srv.URL), RetryOptions{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: tt.maxDelay})
+
+			// Bounded so that an uncapped delay fails the test rather than hanging it
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			var logs []string
+			c.Logf = func(format string, args ...any) {
+				logs = append(logs, fmt.Sprintf(format, args...))
+				if !tt.wait {
+					cancel()
+				}
+			}
+
+			_, err := c.GetIncidentWithContext(ctx, "PINC001")
+
+			if len(logs) != 1 || !strings.HasSuffix(logs[0], tt.want) {
+				t.Fatalf("retry logs = %q, want one ending with %q", logs, tt.want)
+			}
+			if tt.wait && (err != nil || calls != 2) {
+				t.Errorf("error = %v after %v call(s), want success on the retry", err, calls)
+			}
+		})
+	}
+}
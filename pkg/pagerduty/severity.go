@@ -0,0 +1,132 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// Normalised alert severities, from the most to the least severe
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+var Severities = []string{SeverityCritical, SeverityError, SeverityWarning, SeverityInfo}
+
+// severityAliases maps the severities used by PagerDuty, Alertmanager and common monitoring tools
+// onto the normalised severities
+var severityAliases = map[string]string{
+	"critical":      SeverityCritical,
+	"crit":          SeverityCritical,
+	"fatal":         SeverityCritical,
+	"emergency":     SeverityCritical,
+	"alert":         SeverityCritical,
+	"page":          SeverityCritical,
+	"p1":            SeverityCritical,
+	"error":         SeverityError,
+	"err":           SeverityError,
+	"high":          SeverityError,
+	"major":         SeverityError,
+	"p2":            SeverityError,
+	"warning":       SeverityWarning,
+	"warn":          SeverityWarning,
+	"medium":        SeverityWarning,
+	"minor":         SeverityWarning,
+	"p3":            SeverityWarning,
+	"info":          SeverityInfo,
+	"informational": SeverityInfo,
+	"notice":        SeverityInfo,
+	"low":           SeverityInfo,
+	"none":          SeverityInfo,
+	"debug":         SeverityInfo,
+	"p4":            SeverityInfo,
+	"p5":            SeverityInfo,
+}
+
+// NormalizeSeverity returns the normalised severity for s, or "" if s is not a known severity.
+func NormalizeSeverity(s string) string {
+	return severityAliases[strings.ToLower(strings.TrimSpace(s))]
+}
+
+// ParseSeverities normalises a list of severities given on the command line.
+func ParseSeverities(values []string) ([]string, error) {
+	var severities []string
+	for _, v := range values {
+		s := NormalizeSeverity(v)
+		if s == "" {
+			return nil, fmt.Errorf("unknown severity `%v`, must be one of %v", v, Severities)
+		}
+		severities = append(severities, s)
+	}
+	return severities, nil
+}
+
+// SeverityRank orders severities from the most severe (0) to unknown severities (last).
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+// HighestSeverity returns the most severe severity of the alerts, or "" if none is known.
+func HighestSeverity(alerts []Alert) string {
+	highest := ""
+	for _, a := range alerts {
+		if SeverityRank(a.Severity) < SeverityRank(highest) {
+			highest = a.Severity
+		}
+	}
+	return highest
+}
+
+// SortAlertsBySeverity sorts the alerts from the most to the least severe, keeping the order of
+// alerts of the same severity.
+func SortAlertsBySeverity(alerts []Alert) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return SeverityRank(alerts[i].Severity) < SeverityRank(alerts[j].Severity)
+	})
+}
+
+// resolveSeverity returns the first of the candidate severities that can be normalised. Candidates
+// are given by decreasing precedence: mapping rules, then Alertmanager labels, then PagerDuty.
+func resolveSeverity(candidates ...string) string {
+	for _, c := range candidates {
+		if s := NormalizeSeverity(c); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// IncidentSeverity calls IncidentSeverityWithContext with a background context.
+func IncidentSeverity(c PagerDutyClient, id string) (string, []Alert, error) {
+	return IncidentSeverityWithContext(context.Background(), c, id)
+}
+
+// IncidentSeverityWithContext returns the highest severity of the alerts of an incident, along with
+// the parsed alerts. Alerts that could only be partially parsed are still taken into account.
+func IncidentSeverityWithContext(ctx context.Context, c PagerDutyClient, id string) (string, []Alert, error) {
+	alerts, err := GetAlertsWithContext(ctx, c, id, pagerduty.ListIncidentAlertsOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	var parsed []Alert
+	for i := range alerts {
+		var a Alert
+		// A partially parsed alert still carries its PagerDuty severity
+		_ = a.ParseAlertDataWithContext(ctx, c, &alerts[i])
+		parsed = append(parsed, a)
+	}
+
+	return HighestSeverity(parsed), parsed, nil
+}
@@ -0,0 +1,56 @@
+package pd
+
+import (
+	"context"
+	"testing"
+
+	config "github.com/aliceh/alertops/pkg/config"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+func TestParseAlertDataSeverity(t *testing.T) {
+	withMappings(t, config.AlertMapping{
+		Name:  "checks",
+		Match: []config.AlertMatch{{Key: "check"}},
+		Fields: config.AlertMappingFields{
+			Severity: &config.Extraction{Path: "$.level"},
+			Labels:   &config.Extraction{Path: "$.firing"},
+		},
+	})
+
+	labels := func(severity string) string {
+		return "Labels:\n - alertname = KubeAPIDown\n - severity = " + severity + "\n"
+	}
+
+	tests := []struct {
+		name       string
+		details    map[string]interface{}
+		pdSeverity string
+		want       string
+		// The mapped fields are missing, which does not keep the severity from being resolved
+		wantPartial bool
+	}{
+		{name: "details field wins over the label and PagerDuty", details: map[string]interface{}{"check": "api", "level": "P3", "firing": labels("critical")}, pdSeverity: "info", want: SeverityWarning},
+		{name: "details field is normalised", details: map[string]interface{}{"check": "api", "level": " Critical ", "firing": labels("info")}, pdSeverity: "info", want: SeverityCritical},
+		{name: "label wins over PagerDuty when the details field is unknown", details: map[string]interface{}{"check": "api", "level": "sev0", "firing": labels("major")}, pdSeverity: "critical", want: SeverityError},
+		{name: "PagerDuty when the details field and the label are missing", details: map[string]interface{}{"check": "api"}, pdSeverity: "error", want: SeverityError, wantPartial: true},
+		{name: "label of an Alertmanager alert wins over PagerDuty", details: map[string]interface{}{"firing": labels("warning")}, pdSeverity: "critical", want: SeverityWarning},
+		{name: "PagerDuty when the label is unknown", details: map[string]interface{}{"firing": labels("urgent")}, pdSeverity: "info", want: SeverityInfo},
+		{name: "no known severity anywhere", details: map[string]interface{}{"firing": labels("urgent")}, pdSeverity: "sev1", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := newAlert(tt.details)
+			alert.Severity = tt.pdSeverity
+
+			var a Alert
+			if err := a.ParseAlertDataWithContext(context.Background(), fake.New(), alert); (err != nil) != tt.wantPartial {
+				t.Fatalf("ParseAlertData() error = %v, want partial %v", err, tt.wantPartial)
+			}
+			if a.Severity != tt.want {
+				t.Errorf("Severity = %q, want %q", a.Severity, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// SilenceIncidents calls SilenceIncidentsWithContext with a background context.
+func SilenceIncidents(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, silentUser *pagerduty.User, reason string, until time.Time) (*BulkResult, error) {
+	return SilenceIncidentsWithContext(context.Background(), client, incidents, user, silentUser, reason, until)
+}
+
+// SilenceIncidentsWithContext acknowledges the incidents and assigns them to the silent user, then posts a note
+// on each of them explaining who silenced it, why, and until when if until is not zero. The result
+// reports the outcome for each incident; the error lists those it failed on.
+func SilenceIncidentsWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, silentUser *pagerduty.User, reason string, until time.Time) (*BulkResult, error) {
+	opts, err := incidentOptions(incidents, func(o *pagerduty.ManageIncidentsOptions) {
+		o.Status = "acknowledged"
+		o.Assignments = []pagerduty.Assignee{{Assignee: silentUser.APIObject}}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.SilenceIncidents(): %v", err)
+	}
+
+	result := BulkManageIncidentsWithContext(ctx, client, user.Email, opts)
+
+	content := fmt.Sprintf("Silenced by %v", user.Name)
+	if !until.IsZero() {
+		content += fmt.Sprintf(" until %v", until.UTC().Format(time.RFC3339))
+	}
+	if reason != "" {
+		content += ": " + reason
+	}
+
+	postNotes(ctx, client, result, user, content)
+
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.SilenceIncidents(): failed to silence incident(s): %v", err)
+	}
+
+	return result, nil
+}
+
+// UnsilenceIncidents calls UnsilenceIncidentsWithContext with a background context.
+func UnsilenceIncidents(client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, assignees []*pagerduty.User, reason string) (*BulkResult, error) {
+	return UnsilenceIncidentsWithContext(context.Background(), client, incidents, user, assignees, reason)
+}
+
+// UnsilenceIncidentsWithContext lifts a silence by handing the incidents back to the given assignees or, if there
+// are none, re-escalating them from the first level of their escalation policy. A note explaining why
+// is posted on each of them. The result reports the outcome for each incident; the error lists those
+// it failed on.
+func UnsilenceIncidentsWithContext(ctx context.Context, client PagerDutyClient, incidents []*pagerduty.Incident, user *pagerduty.User, assignees []*pagerduty.User, reason string) (*BulkResult, error) {
+	a := []pagerduty.Assignee{}
+	for _, assignee := range assignees {
+		a = append(a, pagerduty.Assignee{Assignee: assignee.APIObject})
+	}
+
+	opts, err := incidentOptions(incidents, func(o *pagerduty.ManageIncidentsOptions) {
+		if len(a) > 0 {
+			o.Assignments = a
+		} else {
+			o.EscalationLevel = 1
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pd.UnsilenceIncidents(): %v", err)
+	}
+
+	result := BulkManageIncidentsWithContext(ctx, client, user.Email, opts)
+
+	content := fmt.Sprintf("Silence lifted by %v", user.Name)
+	if reason != "" {
+		content += ": " + reason
+	}
+
+	postNotes(ctx, client, result, user, content)
+
+	if err := result.Err(); err != nil {
+		return result, fmt.Errorf("pd.UnsilenceIncidents(): failed to unsilence incident(s): %v", err)
+	}
+
+	return result, nil
+}
+
+// postNotes posts the note on every incident the operation was applied to, recording failures in the result
+func postNotes(ctx context.Context, client PagerDutyClient, result *BulkResult, user *pagerduty.User, content string) {
+	for i := range result.Results {
+		r := &result.Results[i]
+		if r.Incident == nil {
+			continue
+		}
+		if _, err := PostNoteWithContext(ctx, client, r.ID, user, content); err != nil {
+			r.Err = fmt.Errorf("failed to post note: %v", err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package pd
+
+import (
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// The summaries below are the structured output of the CLI. Their json/yaml field names are
+// relied on by scripts consuming that output, so fields may be added but must not be renamed
+// or removed.
+
+// IncidentSummary is the flattened view of a PagerDuty incident
+type IncidentSummary struct {
+	ID              string   `json:"id" yaml:"id"`
+	Number          uint     `json:"number" yaml:"number"`
+	Title           string   `json:"title" yaml:"title"`
+	Status          string   `json:"status" yaml:"status"`
+	Urgency         string   `json:"urgency" yaml:"urgency"`
+	Priority        string   `json:"priority" yaml:"priority"`
+	ServiceID       string   `json:"service_id" yaml:"service_id"`
+	Service         string   `json:"service" yaml:"service"`
+	Assignees       []string `json:"assignees" yaml:"assignees"`
+	Acknowledgers   []string `json:"acknowledgers" yaml:"acknowledgers"`
+	AlertsTriggered uint     `json:"alerts_triggered" yaml:"alerts_triggered"`
+	AlertsTotal     uint     `json:"alerts_total" yaml:"alerts_total"`
+	CreatedAt       string   `json:"created_at" yaml:"created_at"`
+	LastChangedAt   string   `json:"last_changed_at" yaml:"last_changed_at"`
+	WebURL          string   `json:"web_url" yaml:"web_url"`
+	// Severity is the highest severity of the incident alerts. It is only set by the listings that
+	// fetch the alerts of each incident.
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+}
+
+// NoteSummary is the flattened view of a note on a PagerDuty incident
+type NoteSummary struct {
+	ID         string `json:"id" yaml:"id"`
+	IncidentID string `json:"incident_id" yaml:"incident_id"`
+	Author     string `json:"author" yaml:"author"`
+	Content    string `json:"content" yaml:"content"`
+	CreatedAt  string `json:"created_at" yaml:"created_at"`
+}
+
+// TeamSummary is the flattened view of a PagerDuty team and its members
+type TeamSummary struct {
+	ID          string   `json:"id" yaml:"id"`
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description" yaml:"description"`
+	MemberIDs   []string `json:"member_ids" yaml:"member_ids"`
+	WebURL      string   `json:"web_url" yaml:"web_url"`
+}
+
+func NewIncidentSummary(i pagerduty.Incident) IncidentSummary {
+	s := IncidentSummary{
+		ID:              i.ID,
+		Number:          i.IncidentNumber,
+		Title:           i.Title,
+		Status:          i.Status,
+		Urgency:         i.Urgency,
+		ServiceID:       i.Service.ID,
+		Service:         i.Service.Summary,
+		Assignees:       []string{},
+		Acknowledgers:   []string{},
+		AlertsTriggered: i.AlertCounts.Triggered,
+		AlertsTotal:     i.AlertCounts.All,
+		CreatedAt:       i.CreatedAt,
+		LastChangedAt:   i.LastStatusChangeAt,
+		WebURL:          i.HTMLURL,
+	}
+
+	if i.Priority != nil {
+		s.Priority = i.Priority.Summary
+	}
+
+	for _, a := range i.Assignments {
+		s.Assignees = append(s.Assignees, a.Assignee.Summary)
+	}
+
+	for _, a := range i.Acknowledgements {
+		s.Acknowledgers = append(s.Acknowledgers, a.Acknowledger.Summary)
+	}
+
+	return s
+}
+
+func NewIncidentSummaries(incidents []pagerduty.Incident) []IncidentSummary {
+	s := []IncidentSummary{}
+	for _, i := range incidents {
+		s = append(s, NewIncidentSummary(i))
+	}
+	return s
+}
+
+func NewNoteSummaries(incidentID string, notes []pagerduty.IncidentNote) []NoteSummary {
+	s := []NoteSummary{}
+	for _, n := range notes {
+		s = append(s, NoteSummary{
+			ID:         n.ID,
+			IncidentID: incidentID,
+			Author:     n.User.Summary,
+			Content:    n.Content,
+			CreatedAt:  n.CreatedAt,
+		})
+	}
+	return s
+}
+
+func NewTeamSummary(team *pagerduty.Team, memberIDs []string) TeamSummary {
+	if memberIDs == nil {
+		memberIDs = []string{}
+	}
+
+	return TeamSummary{
+		ID:          team.ID,
+		Name:        team.Name,
+		Description: team.Description,
+		MemberIDs:   memberIDs,
+		WebURL:      team.HTMLURL,
+	}
+}
@@ -0,0 +1,249 @@
+package pd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// Sources of timeline entries
+const (
+	TimelineLog   = "log"
+	TimelineAlert = "alert"
+	TimelineNote  = "note"
+)
+
+// TimelineEntry is one event in the life of an incident: a PagerDuty log entry, an alert or a note.
+type TimelineEntry struct {
+	At     string `json:"at" yaml:"at"`
+	Source string `json:"source" yaml:"source"`
+	// Type is the kind of log entry without its _log_entry suffix, e.g. acknowledge, or the source
+	Type  string `json:"type" yaml:"type"`
+	Actor string `json:"actor" yaml:"actor"`
+	// Transition is the status change the entry caused, e.g. "triggered -> acknowledged"
+	Transition string `json:"transition,omitempty" yaml:"transition,omitempty"`
+	Summary    string `json:"summary" yaml:"summary"`
+	// Elapsed is the time since the incident was created, Delta the time since the previous entry
+	Elapsed string `json:"elapsed" yaml:"elapsed"`
+	Delta   string `json:"delta" yaml:"delta"`
+}
+
+// Timeline is the chronological history of an incident.
+type Timeline struct {
+	IncidentID string          `json:"incident_id" yaml:"incident_id"`
+	Title      string          `json:"title" yaml:"title"`
+	Status     string          `json:"status" yaml:"status"`
+	Urgency    string          `json:"urgency" yaml:"urgency"`
+	Service    string          `json:"service" yaml:"service"`
+	CreatedAt  string          `json:"created_at" yaml:"created_at"`
+	WebURL     string          `json:"web_url" yaml:"web_url"`
+	Entries    []TimelineEntry `json:"entries" yaml:"entries"`
+}
+
+// statusAfter is the status of an incident after a log entry of each type changing it
+var statusAfter = map[string]string{
+	"trigger":       "triggered",
+	"acknowledge":   "acknowledged",
+	"unacknowledge": "triggered",
+	"escalate":      "triggered",
+	"resolve":       "resolved",
+}
+
+// GetLogEntries calls GetLogEntriesWithContext with a background context.
+func GetLogEntries(client PagerDutyClient, id string) ([]pagerduty.LogEntry, error) {
+	return GetLogEntriesWithContext(context.Background(), client, id)
+}
+
+// GetLogEntriesWithContext returns every log entry of an incident.
+func GetLogEntriesWithContext(ctx context.Context, client PagerDutyClient, id string) ([]pagerduty.LogEntry, error) {
+	var entries []pagerduty.LogEntry
+
+	opts := pagerduty.ListIncidentLogEntriesOptions{Limit: defaultPageLimit, Offset: defaultOffset}
+
+	for {
+		response, err := client.ListIncidentLogEntriesWithContext(ctx, id, opts)
+		if err != nil {
+			return nil, fmt.Errorf("pd.GetLogEntries(): failed to list log entries of incident `%v`: %v", id, err)
+		}
+
+		entries = append(entries, response.LogEntries...)
+
+		if !response.More {
+			break
+		}
+		opts.Offset += opts.Limit
+	}
+
+	return entries, nil
+}
+
+// GetTimeline calls GetTimelineWithContext with a background context.
+func GetTimeline(client PagerDutyClient, id string) (*Timeline, error) {
+	return GetTimelineWithContext(context.Background(), client, id)
+}
+
+// GetTimelineWithContext fetches the log entries, alerts and notes of an incident and merges them
+// into its timeline.
+func GetTimelineWithContext(ctx context.Context, client PagerDutyClient, id string) (*Timeline, error) {
+	incident, err := GetIncidentWithContext(ctx, client, id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := GetLogEntriesWithContext(ctx, client, id)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := GetAlertsWithContext(ctx, client, id, pagerduty.ListIncidentAlertsOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := GetNotesWithContext(ctx, client, id)
+	if err != nil {
+		return nil, err
+	}
+
+	t := BuildTimeline(*incident, entries, alerts, notes)
+	return &t, nil
+}
+
+// BuildTimeline merges the log entries, alerts and notes of an incident into a single chronological
+// list. Annotate log entries are left out, as the notes they stand for are listed with their content.
+func BuildTimeline(incident pagerduty.Incident, entries []pagerduty.LogEntry, alerts []pagerduty.IncidentAlert, notes []pagerduty.IncidentNote) Timeline {
+	t := Timeline{
+		IncidentID: incident.ID,
+		Title:      incident.Title,
+		Status:     incident.Status,
+		Urgency:    incident.Urgency,
+		Service:    incident.Service.Summary,
+		CreatedAt:  incident.CreatedAt,
+		WebURL:     incident.HTMLURL,
+		Entries:    []TimelineEntry{},
+	}
+
+	for _, e := range entries {
+		kind := strings.TrimSuffix(e.Type, "_log_entry")
+		if kind == "annotate" {
+			continue
+		}
+		t.Entries = append(t.Entries, TimelineEntry{
+			At:      e.CreatedAt,
+			Source:  TimelineLog,
+			Type:    kind,
+			Actor:   actor(pagerduty.APIObject(e.Agent)),
+			Summary: e.Summary,
+		})
+	}
+
+	for _, a := range alerts {
+		summary := a.Summary
+		if a.Severity != "" {
+			summary = fmt.Sprintf("[%v] %v", a.Severity, summary)
+		}
+		t.Entries = append(t.Entries, TimelineEntry{
+			At:      a.CreatedAt,
+			Source:  TimelineAlert,
+			Type:    TimelineAlert,
+			Actor:   actor(a.Service),
+			Summary: summary,
+		})
+	}
+
+	for _, n := range notes {
+		t.Entries = append(t.Entries, TimelineEntry{
+			At:      n.CreatedAt,
+			Source:  TimelineNote,
+			Type:    TimelineNote,
+			Actor:   actor(n.User),
+			Summary: n.Content,
+		})
+	}
+
+	sort.SliceStable(t.Entries, func(i, j int) bool {
+		return before(t.Entries[i].At, t.Entries[j].At)
+	})
+
+	status := ""
+	var previous time.Time
+	created, _ := time.Parse(time.RFC3339, incident.CreatedAt)
+	for i := range t.Entries {
+		e := &t.Entries[i]
+
+		if to, ok := statusAfter[e.Type]; ok && e.Source == TimelineLog && to != status {
+			if status != "" {
+				e.Transition = status + " -> " + to
+			} else {
+				e.Transition = to
+			}
+			status = to
+		}
+
+		at, err := time.Parse(time.RFC3339, e.At)
+		if err != nil {
+			continue
+		}
+		if !created.IsZero() {
+			e.Elapsed = formatDelta(at.Sub(created))
+		}
+		if !previous.IsZero() {
+			e.Delta = "+" + formatDelta(at.Sub(previous))
+		}
+		previous = at
+	}
+
+	return t
+}
+
+// Markdown renders the timeline as a Markdown document, e.g. for a postmortem.
+func (t Timeline) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Incident %v: %v\n\n", t.IncidentID, markdownEscape(t.Title))
+	fmt.Fprintf(&b, "- **Status:** %v\n", t.Status)
+	fmt.Fprintf(&b, "- **Urgency:** %v\n", t.Urgency)
+	if t.Service != "" {
+		fmt.Fprintf(&b, "- **Service:** %v\n", markdownEscape(t.Service))
+	}
+	fmt.Fprintf(&b, "- **Created:** %v\n", t.CreatedAt)
+	if t.WebURL != "" {
+		fmt.Fprintf(&b, "- **PagerDuty:** <%v>\n", t.WebURL)
+	}
+
+	b.WriteString("\n## Timeline\n\n")
+	b.WriteString("| Time | Elapsed | Delta | Type | Actor | Status | Details |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, e := range t.Entries {
+		fmt.Fprintf(&b, "| %v | %v | %v | %v | %v | %v | %v |\n",
+			e.At, e.Elapsed, e.Delta, e.Type, markdownCell(e.Actor), markdownCell(e.Transition), markdownCell(e.Summary))
+	}
+
+	return b.String()
+}
+
+func actor(o pagerduty.APIObject) string {
+	if o.Summary != "" {
+		return o.Summary
+	}
+	return o.ID
+}
+
+// formatDelta formats a duration to the second, e.g. 1h2m3s
+func formatDelta(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+// markdownEscape escapes the characters Markdown would otherwise interpret in plain text
+func markdownEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// markdownCell escapes text for a table cell, which must fit on one line
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(markdownEscape(strings.TrimSpace(s)))
+}
@@ -0,0 +1,135 @@
+package pd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+func logEntry(kind, at, agent, summary string) pagerduty.LogEntry {
+	return pagerduty.LogEntry{CommonLogEntryField: pagerduty.CommonLogEntryField{
+		APIObject: pagerduty.APIObject{Type: kind + "_log_entry", Summary: summary},
+		CreatedAt: at,
+		Agent:     pagerduty.Agent{ID: "P" + strings.ToUpper(agent), Summary: agent},
+	}}
+}
+
+// newTimeline returns the timeline of an incident acknowledged twice, escalated and resolved, with an
+// alert and a note at the same time as log entries and an entry PagerDuty gave an invalid time
+func newTimeline(createdAt string) Timeline {
+	incident := pagerduty.Incident{
+		APIObject: pagerduty.APIObject{ID: "PINC1", HTMLURL: "https://example.pagerduty.com/incidents/PINC1"},
+		Title:     "Disk *full* on [node-3]",
+		Status:    "resolved",
+		Urgency:   "high",
+		Service:   pagerduty.APIObject{Summary: "prod-east-1"},
+		CreatedAt: createdAt,
+	}
+
+	entries := []pagerduty.LogEntry{
+		logEntry("resolve", "2024-05-01T11:00:00Z", "Jane", "Resolved by Jane"),
+		logEntry("trigger", "2024-05-01T10:00:00Z", "Alertmanager", "Triggered through the API"),
+		logEntry("notify", "yesterday", "PagerDuty", "Notified Jane"),
+		logEntry("acknowledge", "2024-05-01T10:05:00Z", "Jane", "Acknowledged by Jane"),
+		// Stands for the note, which is listed with its content
+		logEntry("annotate", "2024-05-01T10:05:00Z", "Jane", "Note added"),
+		logEntry("acknowledge", "2024-05-01T10:06:00Z", "Jane", "Acknowledged by Jane"),
+		logEntry("escalate", "2024-05-01T10:30:00Z", "John", "Escalated to level 2"),
+	}
+	alerts := []pagerduty.IncidentAlert{{
+		APIObject: pagerduty.APIObject{Summary: "KubeNodeDiskFull"},
+		CreatedAt: "2024-05-01T10:00:00Z",
+		Severity:  "critical",
+		Service:   pagerduty.APIObject{ID: "PSVC1"},
+	}}
+	notes := []pagerduty.IncidentNote{{
+		CreatedAt: "2024-05-01T10:05:00Z",
+		User:      pagerduty.APIObject{Summary: "Jane"},
+		Content:   "Looking into it | cleaning up\nlogs on node-3\n",
+	}}
+
+	return BuildTimeline(incident, entries, alerts, notes)
+}
+
+func TestBuildTimeline(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt string
+		want      []string
+	}{
+		{
+			name:      "sorted with transitions",
+			createdAt: "2024-05-01T10:00:00Z",
+			want: []string{
+				// Entries at the same time keep the order of log entries, alerts and notes
+				"log|trigger|Alertmanager|triggered|0s|",
+				"alert|alert|PSVC1||0s|+0s",
+				"log|acknowledge|Jane|triggered -> acknowledged|5m0s|+5m0s",
+				"note|note|Jane||5m0s|+0s",
+				// Acknowledging again changes nothing
+				"log|acknowledge|Jane||6m0s|+1m0s",
+				"log|escalate|John|acknowledged -> triggered|30m0s|+24m0s",
+				"log|resolve|Jane|triggered -> resolved|1h0m0s|+30m0s",
+				// An invalid time sorts last, without elapsed time or delta
+				"log|notify|PagerDuty|||",
+			},
+		},
+		{
+			name:      "invalid creation time",
+			createdAt: "unknown",
+			want: []string{
+				"log|trigger|Alertmanager|triggered||",
+				"alert|alert|PSVC1|||+0s",
+				"log|acknowledge|Jane|triggered -> acknowledged||+5m0s",
+				"note|note|Jane|||+0s",
+				"log|acknowledge|Jane|||+1m0s",
+				"log|escalate|John|acknowledged -> triggered||+24m0s",
+				"log|resolve|Jane|triggered -> resolved||+30m0s",
+				"log|notify|PagerDuty|||",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range newTimeline(tt.createdAt).Entries {
+				got = append(got, fmt.Sprintf("%v|%v|%v|%v|%v|%v", e.Source, e.Type, e.Actor, e.Transition, e.Elapsed, e.Delta))
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("entries =\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestTimelineMarkdown(t *testing.T) {
+	want := `# Incident PINC1: Disk \*full\* on \[node-3\]
+
+- **Status:** resolved
+- **Urgency:** high
+- **Service:** prod-east-1
+- **Created:** 2024-05-01T10:00:00Z
+- **PagerDuty:** <https://example.pagerduty.com/incidents/PINC1>
+
+## Timeline
+
+| Time | Elapsed | Delta | Type | Actor | Status | Details |
+|---|---|---|---|---|---|---|
+| 2024-05-01T10:00:00Z | 0s |  | trigger | Alertmanager | triggered | Triggered through the API |
+| 2024-05-01T10:00:00Z | 0s | +0s | alert | PSVC1 |  | \[critical\] KubeNodeDiskFull |
+| 2024-05-01T10:05:00Z | 5m0s | +5m0s | acknowledge | Jane | triggered -&gt; acknowledged | Acknowledged by Jane |
+| 2024-05-01T10:05:00Z | 5m0s | +0s | note | Jane |  | Looking into it \| cleaning up<br>logs on node-3 |
+| 2024-05-01T10:06:00Z | 6m0s | +1m0s | acknowledge | Jane |  | Acknowledged by Jane |
+| 2024-05-01T10:30:00Z | 30m0s | +24m0s | escalate | John | acknowledged -&gt; triggered | Escalated to level 2 |
+| 2024-05-01T11:00:00Z | 1h0m0s | +30m0s | resolve | Jane | triggered -&gt; resolved | Resolved by Jane |
+| yesterday |  |  | notify | PagerDuty |  | Notified Jane |
+`
+
+	if got := newTimeline("2024-05-01T10:00:00Z").Markdown(); got != want {
+		t.Errorf("Markdown() =\n%v\nwant\n%v", got, want)
+	}
+}
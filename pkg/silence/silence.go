@@ -0,0 +1,160 @@
+package silence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+)
+
+// Silence records a timed silence, so the incident can be handed back once it expires
+type Silence struct {
+	IncidentID string    `json:"incident_id"`
+	SilencedBy string    `json:"silenced_by"`
+	Reason     string    `json:"reason,omitempty"`
+	Until      time.Time `json:"until"`
+
+	// IDs of the users the incident was assigned to before it was silenced
+	AssigneeIDs []string `json:"assignee_ids,omitempty"`
+}
+
+// Store is the set of active timed silences, persisted as a JSON file
+type Store struct {
+	path     string
+	silences map[string]Silence
+}
+
+// Load reads the silences stored at path. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, silences: map[string]Silence{}}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("silence.Load(): failed to read `%v`: %v", path, err)
+	}
+
+	var silences []Silence
+	if err := json.Unmarshal(b, &silences); err != nil {
+		return nil, fmt.Errorf("silence.Load(): failed to parse `%v`: %v", path, err)
+	}
+
+	for _, silence := range silences {
+		s.silences[silence.IncidentID] = silence
+	}
+
+	return s, nil
+}
+
+// Save writes the store back to its file.
+func (s *Store) Save() error {
+	b, err := json.MarshalIndent(s.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("silence.Save(): failed to create `%v`: %v", filepath.Dir(s.path), err)
+	}
+
+	if err := os.WriteFile(s.path, b, 0o600); err != nil {
+		return fmt.Errorf("silence.Save(): failed to write `%v`: %v", s.path, err)
+	}
+
+	return nil
+}
+
+// Add records a silence, replacing any earlier silence of the same incident.
+func (s *Store) Add(silence Silence) {
+	s.silences[silence.IncidentID] = silence
+}
+
+// Remove forgets the silence of an incident.
+func (s *Store) Remove(incidentID string) {
+	delete(s.silences, incidentID)
+}
+
+func (s *Store) Get(incidentID string) (Silence, bool) {
+	silence, ok := s.silences[incidentID]
+	return silence, ok
+}
+
+// List returns every silence, soonest to expire first.
+func (s *Store) List() []Silence {
+	l := []Silence{}
+	for _, silence := range s.silences {
+		l = append(l, silence)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Until.Before(l[j].Until) })
+	return l
+}
+
+// Expired returns the silences whose window has ended by now.
+func (s *Store) Expired(now time.Time) []Silence {
+	var l []Silence
+	for _, silence := range s.List() {
+		if !silence.Until.After(now) {
+			l = append(l, silence)
+		}
+	}
+	return l
+}
+
+// Lift hands a silenced incident back to the users it was assigned to before it was silenced, or
+// re-escalates it if there were none. Incidents resolved in the meantime are left alone.
+func Lift(ctx context.Context, client pd.PagerDutyClient, user *pagerduty.User, silence Silence, reason string) error {
+	incident, err := pd.GetIncidentWithContext(ctx, client, silence.IncidentID)
+	if err != nil {
+		return err
+	}
+
+	if incident.Status == "resolved" {
+		return nil
+	}
+
+	var assignees []*pagerduty.User
+	for _, id := range silence.AssigneeIDs {
+		assignees = append(assignees, &pagerduty.User{APIObject: pagerduty.APIObject{ID: id, Type: "user_reference"}})
+	}
+
+	_, err = pd.UnsilenceIncidentsWithContext(ctx, client, []*pagerduty.Incident{incident}, user, assignees, reason)
+	return err
+}
+
+// Expire lifts every silence whose window has ended by now and removes it from the store, saving
+// the store as it goes. A silence that cannot be lifted is kept and does not stop the others from
+// being lifted. It returns the silences that were lifted, and the errors of those that were not.
+func (s *Store) Expire(ctx context.Context, client pd.PagerDutyClient, user *pagerduty.User, now time.Time) ([]Silence, error) {
+	var lifted []Silence
+	var errs []error
+
+	for _, silence := range s.Expired(now) {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		reason := fmt.Sprintf("silence set by %v expired", silence.SilencedBy)
+		if err := Lift(ctx, client, user, silence, reason); err != nil {
+			errs = append(errs, fmt.Errorf("silence.Expire(): failed to lift silence of incident `%v`: %v", silence.IncidentID, err))
+			continue
+		}
+
+		s.Remove(silence.IncidentID)
+		if err := s.Save(); err != nil {
+			return lifted, errors.Join(append(errs, err)...)
+		}
+		lifted = append(lifted, silence)
+	}
+
+	return lifted, errors.Join(errs...)
+}
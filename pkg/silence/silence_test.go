@@ -0,0 +1,127 @@
+package silence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/pagerduty/fake"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestStoreLoadSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "silences.json")
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of a missing file error = %v", err)
+	}
+	if len(s.List()) != 0 {
+		t.Fatalf("List() = %v, want an empty store", s.List())
+	}
+
+	s.Add(Silence{IncidentID: "PINC2", SilencedBy: "Jane Doe", Until: now.Add(2 * time.Hour), AssigneeIDs: []string{"PUSER1"}})
+	s.Add(Silence{IncidentID: "PINC1", SilencedBy: "Jane Doe", Reason: "maintenance", Until: now.Add(time.Hour)})
+	s.Add(Silence{IncidentID: "PINC3", Until: now})
+	s.Remove("PINC3")
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got := loaded.List()
+	if len(got) != 2 || got[0].IncidentID != "PINC1" || got[1].IncidentID != "PINC2" {
+		t.Fatalf("List() = %+v, want PINC1 then PINC2, soonest to expire first", got)
+	}
+	if got[0].Reason != "maintenance" || !got[0].Until.Equal(now.Add(time.Hour)) || got[1].AssigneeIDs[0] != "PUSER1" {
+		t.Errorf("List() = %+v, want the silences as saved", got)
+	}
+	if _, ok := loaded.Get("PINC3"); ok {
+		t.Errorf("removed silence PINC3 was saved")
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Errorf("Load() of a corrupt file succeeded")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	s, _ := Load(filepath.Join(t.TempDir(), "silences.json"))
+	s.Add(Silence{IncidentID: "PPAST", Until: now.Add(-time.Minute)})
+	s.Add(Silence{IncidentID: "PNOW", Until: now})
+	s.Add(Silence{IncidentID: "PSOON", Until: now.Add(time.Nanosecond)})
+
+	var ids []string
+	for _, silence := range s.Expired(now) {
+		ids = append(ids, silence.IncidentID)
+	}
+	if strings.Join(ids, ",") != "PPAST,PNOW" {
+		t.Errorf("Expired() = %v, want the silences ending at or before now", ids)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	c := fake.New()
+	c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER1"}, Name: "Jane Doe", Email: "jane@example.com"})
+	c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER2"}, Name: "John Doe", Email: "john@example.com"})
+	c.AddUser(pagerduty.User{APIObject: pagerduty.APIObject{ID: "PSILENT"}, Name: "Silent Test"})
+	for _, id := range []string{"PINC1", "PINC3", "PINC4"} {
+		c.AddIncident(pagerduty.Incident{
+			APIObject:   pagerduty.APIObject{ID: id},
+			Status:      "acknowledged",
+			Assignments: []pagerduty.Assignment{{Assignee: pagerduty.APIObject{ID: "PSILENT"}}},
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "silences.json")
+	s, _ := Load(path)
+	s.Add(Silence{IncidentID: "PINC1", SilencedBy: "Jane Doe", Until: now.Add(-time.Hour), AssigneeIDs: []string{"PUSER2"}})
+	// Deleted since it was silenced, so it can never be lifted
+	s.Add(Silence{IncidentID: "PGONE", SilencedBy: "Jane Doe", Until: now.Add(-time.Minute), AssigneeIDs: []string{"PUSER2"}})
+	s.Add(Silence{IncidentID: "PINC3", SilencedBy: "Jane Doe", Until: now, AssigneeIDs: []string{"PUSER2"}})
+	s.Add(Silence{IncidentID: "PINC4", SilencedBy: "Jane Doe", Until: now.Add(time.Hour), AssigneeIDs: []string{"PUSER2"}})
+
+	user := &pagerduty.User{APIObject: pagerduty.APIObject{ID: "PUSER1"}, Name: "Jane Doe", Email: "jane@example.com"}
+	lifted, err := s.Expire(context.Background(), c, user, now)
+
+	if err == nil || !strings.Contains(err.Error(), "failed to lift silence of incident `PGONE`") {
+		t.Errorf("Expire() error = %v, want PGONE reported", err)
+	}
+	if len(lifted) != 2 || lifted[0].IncidentID != "PINC1" || lifted[1].IncidentID != "PINC3" {
+		t.Errorf("Expire() lifted %+v, want PINC1 and PINC3 despite PGONE", lifted)
+	}
+
+	for id, want := range map[string]string{"PINC1": "PUSER2", "PINC3": "PUSER2", "PINC4": "PSILENT"} {
+		incident, err := c.GetIncidentWithContext(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetIncident(%v) error = %v", id, err)
+		}
+		if len(incident.Assignments) != 1 || incident.Assignments[0].Assignee.ID != want {
+			t.Errorf("%v is assigned to %+v, want %v", id, incident.Assignments, want)
+		}
+	}
+
+	// The failed silence is kept to be retried, along with the one still running
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var kept []string
+	for _, silence := range loaded.List() {
+		kept = append(kept, silence.IncidentID)
+	}
+	if strings.Join(kept, ",") != "PGONE,PINC4" {
+		t.Errorf("store keeps %v, want PGONE and PINC4", kept)
+	}
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	utils "github.com/aliceh/alertops/pkg/utils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func (d *Dashboard) acknowledge() {
+	target, incidents := d.targets()
+	if len(incidents) == 0 {
+		return
+	}
+
+	ack := func() {
+		d.perform(fmt.Sprintf("Acknowledging %v", target), func() error {
+			_, err := pd.AcknowledgeIncidentWithContext(d.ctx, d.config.Client, incidents, d.config.CurrentUser)
+			return err
+		})
+	}
+
+	// Acknowledging a whole cluster is worth a second look
+	if len(incidents) > 1 {
+		d.confirm(fmt.Sprintf("Acknowledge %v?", target), ack)
+		return
+	}
+	ack()
+}
+
+func (d *Dashboard) reassign() {
+	incident := d.selected()
+	if incident == nil {
+		return
+	}
+
+	d.prompt(fmt.Sprintf("Reassign %v to user ID: ", incident.ID), func(id string) {
+		d.perform(fmt.Sprintf("Reassigning %v to %v", incident.ID, id), func() error {
+			user, err := pd.GetUserWithContext(d.ctx, d.config.Client, id, pagerduty.GetUserOptions{})
+			if err != nil {
+				return err
+			}
+			_, err = pd.ReassignIncidentsWithContext(d.ctx, d.config.Client, []*pagerduty.Incident{incident}, d.config.CurrentUser, []*pagerduty.User{user})
+			return err
+		})
+	})
+}
+
+func (d *Dashboard) silence() {
+	target, incidents := d.targets()
+	if len(incidents) == 0 || d.config.SilentUser == nil {
+		return
+	}
+
+	d.confirm(fmt.Sprintf("Silence %v by reassigning to %v?", target, d.config.SilentUser.Name), func() {
+		d.perform(fmt.Sprintf("Silencing %v", target), func() error {
+			_, err := pd.SilenceIncidentsWithContext(d.ctx, d.config.Client, incidents, d.config.CurrentUser, d.config.SilentUser, "silenced from the dashboard", time.Time{})
+			return err
+		})
+	})
+}
+
+func (d *Dashboard) note() {
+	target, incidents := d.targets()
+	if len(incidents) == 0 {
+		return
+	}
+
+	d.prompt(fmt.Sprintf("Note for %v: ", target), func(content string) {
+		d.perform(fmt.Sprintf("Adding note to %v", target), func() error {
+			_, err := pd.PostNotesWithContext(d.ctx, d.config.Client, incidents, d.config.CurrentUser, content)
+			return err
+		})
+	})
+}
+
+func (d *Dashboard) resolve() {
+	target, incidents := d.targets()
+	if len(incidents) == 0 {
+		return
+	}
+
+	d.confirm(fmt.Sprintf("Resolve %v?", target), func() {
+		d.perform(fmt.Sprintf("Resolving %v", target), func() error {
+			_, err := pd.ResolveIncidentsWithContext(d.ctx, d.config.Client, incidents, d.config.CurrentUser)
+			return err
+		})
+	})
+}
+
+func (d *Dashboard) snooze() {
+	target, incidents := d.targets()
+	if len(incidents) == 0 {
+		return
+	}
+
+	d.prompt(fmt.Sprintf("Snooze %v for (e.g. 30m, 4h): ", target), func(text string) {
+		duration, err := time.ParseDuration(text)
+		if err != nil || duration < time.Second {
+			d.setStatus(fmt.Sprintf("[red]Invalid snooze duration `%v`", tview.Escape(text)))
+			return
+		}
+
+		d.confirm(fmt.Sprintf("Snooze %v for %v?", target, duration), func() {
+			d.perform(fmt.Sprintf("Snoozing %v for %v", target, duration), func() error {
+				_, err := pd.SnoozeIncidentsWithContext(d.ctx, d.config.Client, incidents, d.config.CurrentUser, duration)
+				return err
+			})
+		})
+	})
+}
+
+func (d *Dashboard) escalate() {
+	target, incidents := d.targets()
+	if len(incidents) == 0 {
+		return
+	}
+
+	d.confirm(fmt.Sprintf("Escalate %v to the next level?", target), func() {
+		d.perform(fmt.Sprintf("Escalating %v", target), func() error {
+			_, err := pd.EscalateIncidentsWithContext(d.ctx, d.config.Client, incidents, d.config.CurrentUser)
+			return err
+		})
+	})
+}
+
+// toggleUrgency switches the targeted incidents to low urgency if the first of them is high, to high otherwise
+func (d *Dashboard) toggleUrgency() {
+	target, incidents := d.targets()
+	if len(incidents) == 0 {
+		return
+	}
+
+	urgency := "high"
+	if incidents[0].Urgency == "high" {
+		urgency = "low"
+	}
+
+	d.confirm(fmt.Sprintf("Set %v to %v urgency?", target, urgency), func() {
+		d.perform(fmt.Sprintf("Setting %v to %v urgency", target, urgency), func() error {
+			_, err := pd.SetUrgencyWithContext(d.ctx, d.config.Client, incidents, d.config.CurrentUser, urgency)
+			return err
+		})
+	})
+}
+
+func (d *Dashboard) setPriority() {
+	target, incidents := d.targets()
+	if len(incidents) == 0 {
+		return
+	}
+
+	d.prompt(fmt.Sprintf("Priority of %v (e.g. P1): ", target), func(priority string) {
+		d.confirm(fmt.Sprintf("Set %v to priority %v?", target, priority), func() {
+			d.perform(fmt.Sprintf("Setting %v to priority %v", target, priority), func() error {
+				_, err := pd.SetPriorityWithContext(d.ctx, d.config.Client, incidents, d.config.CurrentUser, priority)
+				return err
+			})
+		})
+	})
+}
+
+// perform runs a PagerDuty action in the background, reporting progress and errors in
+// the status bar, and reloads the incidents once it succeeds
+func (d *Dashboard) perform(description string, action func() error) {
+	d.setStatus(fmt.Sprintf("[yellow]%v...", tview.Escape(description)))
+
+	go func() {
+		if err := action(); err != nil {
+			utils.ErrorLogger.Printf("%v failed. The error message was : %s", description, err)
+			d.app.QueueUpdateDraw(func() {
+				d.setStatus(fmt.Sprintf("[red]%v failed: %v", tview.Escape(description), tview.Escape(err.Error())))
+			})
+			return
+		}
+
+		utils.InfoLogger.Printf("%v done", description)
+		d.load()
+	}()
+}
+
+// prompt asks for a single line of input and calls done with it unless the user cancels
+func (d *Dashboard) prompt(label string, done func(text string)) {
+	input := tview.NewInputField().SetLabel(label)
+	input.SetBorder(true)
+	input.SetDoneFunc(func(key tcell.Key) {
+		d.pages.RemovePage(pagePrompt)
+		d.app.SetFocus(d.table)
+
+		text := strings.TrimSpace(input.GetText())
+		if key == tcell.KeyEnter && text != "" {
+			done(text)
+		}
+	})
+
+	d.pages.AddPage(pagePrompt, centered(input, 80, 3), true, true)
+	d.app.SetFocus(input)
+}
+
+// confirm asks a yes/no question and calls done if the user agrees
+func (d *Dashboard) confirm(question string, done func()) {
+	modal := tview.NewModal().
+		SetText(question).
+		AddButtons([]string{"Yes", "No"}).
+		SetDoneFunc(func(index int, label string) {
+			d.pages.RemovePage(pageModal)
+			d.app.SetFocus(d.table)
+
+			if label == "Yes" {
+				done()
+			}
+		})
+
+	d.pages.AddPage(pageModal, modal, true, true)
+	d.app.SetFocus(modal)
+}
+
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// toggleClusterView switches the table between incidents and the clusters they fire on
+func (d *Dashboard) toggleClusterView() {
+	d.mu.Lock()
+	d.byCluster = !d.byCluster
+	byCluster := d.byCluster
+	d.mu.Unlock()
+
+	if byCluster {
+		d.table.SetTitle(" Clusters ")
+	} else {
+		d.table.SetTitle(" Incidents ")
+	}
+
+	d.table.Select(1, 0)
+	d.renderTable()
+}
+
+func (d *Dashboard) renderClusterTable(groups []pd.ClusterGroup) {
+	for col, header := range []string{"CLUSTER ID", "CLUSTER NAME", "INCIDENTS", "ALERTS", "URGENCY", "SEVERITY", "OLDEST TRIGGER"} {
+		d.table.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+
+	for i, g := range groups {
+		color := tcell.ColorWhite
+		if g.HighestUrgency == "high" {
+			color = tcell.ColorRed
+		}
+
+		d.table.SetCell(i+1, 0, tview.NewTableCell(g.ClusterID).SetTextColor(color))
+		d.table.SetCell(i+1, 1, tview.NewTableCell(g.ClusterName).SetTextColor(color))
+		d.table.SetCell(i+1, 2, tview.NewTableCell(fmt.Sprint(len(g.IncidentIDs))))
+		d.table.SetCell(i+1, 3, tview.NewTableCell(fmt.Sprint(g.Alerts)))
+		d.table.SetCell(i+1, 4, tview.NewTableCell(g.HighestUrgency))
+		d.table.SetCell(i+1, 5, tview.NewTableCell(g.Severity).SetTextColor(severityColor(g.Severity)))
+		d.table.SetCell(i+1, 6, tview.NewTableCell(g.OldestTrigger).SetExpansion(1))
+	}
+}
+
+func (d *Dashboard) renderClusterDetails(g *pd.ClusterGroup) {
+	d.details.Clear()
+	d.details.ScrollToBeginning()
+
+	fmt.Fprintf(d.details, "[yellow]%v[white]\n", tview.Escape(g.ClusterName))
+	field(d.details, "Cluster ID", g.ClusterID)
+	field(d.details, "Urgency", g.HighestUrgency)
+	if g.Severity != "" {
+		fmt.Fprintf(d.details, "[gray]Severity:[%v] %v[white]\n", output.SeverityColor(g.Severity), g.Severity)
+	}
+	field(d.details, "Alerts", fmt.Sprint(g.Alerts))
+	field(d.details, "Oldest trigger", g.OldestTrigger)
+	for _, sop := range g.Sops {
+		field(d.details, "SOP", sop)
+	}
+
+	for _, incident := range g.Incidents {
+		fmt.Fprintf(d.details, "\n[yellow]Incident %v[white]\n", incident.ID)
+		field(d.details, "Title", incident.Title)
+		field(d.details, "Status", incident.Status)
+		field(d.details, "Urgency", incident.Urgency)
+		field(d.details, "Created", incident.CreatedAt)
+	}
+}
+
+// selectedGroup returns the cluster on the highlighted table row, if the clusters are shown
+func (d *Dashboard) selectedGroup() *pd.ClusterGroup {
+	row, _ := d.table.GetSelection()
+	return d.groupAt(row)
+}
+
+func (d *Dashboard) groupAt(row int) *pd.ClusterGroup {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.byCluster || row < 1 || row > len(d.groups) {
+		return nil
+	}
+
+	g := d.groups[row-1]
+	return &g
+}
+
+// targets returns the incidents the bulk actions apply to, every incident of the selected cluster
+// or the selected incident, and how to refer to them
+func (d *Dashboard) targets() (string, []*pagerduty.Incident) {
+	if g := d.selectedGroup(); g != nil {
+		var incidents []*pagerduty.Incident
+		for i := range g.Incidents {
+			incidents = append(incidents, &g.Incidents[i])
+		}
+		return fmt.Sprintf("the %v incident(s) of %v", len(incidents), g.ClusterName), incidents
+	}
+
+	if incident := d.selected(); incident != nil {
+		return incident.ID, []*pagerduty.Incident{incident}
+	}
+
+	return "", nil
+}
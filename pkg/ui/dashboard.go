@@ -0,0 +1,407 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	"github.com/aliceh/alertops/pkg/silence"
+	utils "github.com/aliceh/alertops/pkg/utils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	pageMain     = "main"
+	pagePrompt   = "prompt"
+	pageModal    = "modal"
+	pageRunbook  = "runbook"
+	pageTimeline = "timeline"
+
+	helpText = "[yellow]a[white] ack  [yellow]x[white] resolve  [yellow]z[white] snooze  [yellow]e[white] escalate  [yellow]u[white] urgency  [yellow]p[white] priority  [yellow]r[white] reassign  [yellow]s[white] silence  [yellow]n[white] note  [yellow]o[white] runbook  [yellow]t[white] timeline  [yellow]f[white] filter severity  [yellow]g[white] group by cluster  [yellow]R[white] refresh  [yellow]q[white] quit"
+)
+
+// Dashboard is a full-screen view of the incidents assigned to the configured teams
+type Dashboard struct {
+	// ctx is cancelled when the dashboard stops, aborting any PagerDuty call in flight
+	ctx context.Context
+
+	app     *tview.Application
+	pages   *tview.Pages
+	table   *tview.Table
+	details *tview.TextView
+	status  *tview.TextView
+
+	config   *pd.Config
+	users    []string
+	refresh  time.Duration
+	silences string
+
+	// expiring serialises the lifting of expired silences between concurrent reloads
+	expiring sync.Mutex
+
+	mu sync.Mutex
+	// all holds every incident loaded, incidents the ones shown, sorted by severity
+	all         []pagerduty.Incident
+	incidents   []pagerduty.Incident
+	alerts      map[string][]pd.Alert
+	severities  map[string]string
+	minSeverity string
+
+	// byCluster shows the shown incidents grouped by cluster instead
+	byCluster bool
+	groups    []pd.ClusterGroup
+}
+
+// NewDashboard creates a dashboard listing the incidents assigned to the given users,
+// refreshing them every refresh interval. Timed silences kept in the silences file are
+// lifted as they expire.
+func NewDashboard(c *pd.Config, users []string, refresh time.Duration, silences string) *Dashboard {
+	d := &Dashboard{
+		ctx:      context.Background(),
+		app:      tview.NewApplication(),
+		pages:    tview.NewPages(),
+		table:    tview.NewTable(),
+		details:  tview.NewTextView(),
+		status:   tview.NewTextView(),
+		config:   c,
+		users:    users,
+		refresh:  refresh,
+		silences: silences,
+		alerts:   map[string][]pd.Alert{},
+
+		severities: map[string]string{},
+	}
+
+	d.table.SetSelectable(true, false).SetFixed(1, 0)
+	d.table.SetBorder(true).SetTitle(" Incidents ")
+	d.table.SetSelectionChangedFunc(func(row, column int) {
+		d.showDetails(row)
+	})
+
+	d.details.SetDynamicColors(true).SetWrap(true)
+	d.details.SetBorder(true).SetTitle(" Details ")
+
+	d.status.SetDynamicColors(true)
+	d.setStatus(helpText)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewFlex().
+			AddItem(d.table, 0, 3, true).
+			AddItem(d.details, 0, 2, false), 0, 1, true).
+		AddItem(d.status, 1, 0, false)
+
+	d.pages.AddPage(pageMain, layout, true, true)
+	d.app.SetRoot(d.pages, true).SetFocus(d.table)
+	d.app.SetInputCapture(d.handleKey)
+
+	return d
+}
+
+// Run loads the incidents and blocks until the user quits the dashboard or ctx is cancelled
+func (d *Dashboard) Run(ctx context.Context) error {
+	var cancel context.CancelFunc
+	d.ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-d.ctx.Done()
+		d.app.Stop()
+	}()
+
+	go d.load()
+
+	if d.refresh > 0 {
+		// Stopping the ticker does not close its channel, so the refresh loop ends with d.ctx, once Run returns
+		go func() {
+			ticker := time.NewTicker(d.refresh)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					d.load()
+				case <-d.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	return d.app.Run()
+}
+
+func (d *Dashboard) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	// Keys typed into a prompt or modal belong to that prompt
+	if name, _ := d.pages.GetFrontPage(); name != pageMain {
+		return event
+	}
+
+	switch event.Rune() {
+	case 'q':
+		d.app.Stop()
+		return nil
+	case 'R':
+		go d.load()
+		return nil
+	case 'a':
+		d.acknowledge()
+		return nil
+	case 'x':
+		d.resolve()
+		return nil
+	case 'z':
+		d.snooze()
+		return nil
+	case 'e':
+		d.escalate()
+		return nil
+	case 'u':
+		d.toggleUrgency()
+		return nil
+	case 'p':
+		d.setPriority()
+		return nil
+	case 'r':
+		d.reassign()
+		return nil
+	case 's':
+		d.silence()
+		return nil
+	case 'n':
+		d.note()
+		return nil
+	case 'o':
+		d.openRunbook()
+		return nil
+	case 't':
+		d.showTimeline()
+		return nil
+	case 'f':
+		d.cycleSeverityFilter()
+		return nil
+	case 'g':
+		d.toggleClusterView()
+		return nil
+	}
+
+	return event
+}
+
+// load fetches the incidents from PagerDuty and redraws the table
+func (d *Dashboard) load() {
+	d.app.QueueUpdateDraw(func() { d.setStatus("[yellow]Refreshing incidents...") })
+
+	d.expireSilences()
+
+	opts := pd.NewListIncidentOptsFromDefaults()
+	opts.UserIDs = d.users
+
+	incidents, err := pd.GetIncidentsWithContext(d.ctx, d.config.Client, opts)
+	if err != nil {
+		utils.ErrorLogger.Printf("Error while refreshing incidents. The error message was : %s", err)
+		d.app.QueueUpdateDraw(func() { d.setStatus(fmt.Sprintf("[red]%v", err)) })
+		return
+	}
+
+	// The severity of an incident is the highest severity of its alerts, which are kept for the details view
+	enriched, err := pd.EnrichIncidentsWithContext(d.ctx, d.config.Client, incidents, pd.EnrichOptions{
+		WithoutNotes:     true,
+		WithoutAssignees: true,
+		WithoutService:   true,
+	})
+	if err != nil {
+		return
+	}
+
+	alerts := map[string][]pd.Alert{}
+	severities := map[string]string{}
+	for _, e := range enriched {
+		if len(e.Errors) > 0 {
+			utils.ErrorLogger.Printf("Error while fetching alerts for incident %v. The error message was : %s", e.Incident.ID, strings.Join(e.Errors, "; "))
+		}
+		alerts[e.Incident.ID] = e.Alerts
+		severities[e.Incident.ID] = e.Severity
+	}
+
+	d.mu.Lock()
+	d.all = incidents
+	d.alerts = alerts
+	d.severities = severities
+	d.filterIncidents()
+	d.mu.Unlock()
+
+	d.app.QueueUpdateDraw(func() {
+		d.renderTable()
+		d.setStatus(fmt.Sprintf("%v  [gray]updated %v", helpText, time.Now().Format("15:04:05")))
+	})
+}
+
+func (d *Dashboard) renderTable() {
+	row, _ := d.table.GetSelection()
+	d.table.Clear()
+
+	// Selecting a row calls showDetails, which takes d.mu, so render from a copy
+	d.mu.Lock()
+	incidents := d.incidents
+	severities := d.severities
+	groups := d.groups
+	byCluster := d.byCluster
+	d.mu.Unlock()
+
+	rows := len(incidents)
+	if byCluster {
+		rows = len(groups)
+		d.renderClusterTable(groups)
+	} else {
+		d.renderIncidentTable(incidents, severities)
+	}
+
+	switch {
+	case rows == 0:
+		d.details.Clear()
+	case row < 1:
+		d.table.Select(1, 0)
+	case row > rows:
+		d.table.Select(rows, 0)
+	default:
+		d.table.Select(row, 0)
+	}
+}
+
+func (d *Dashboard) renderIncidentTable(incidents []pagerduty.Incident, severities map[string]string) {
+	for col, header := range []string{"ID", "STATUS", "URGENCY", "SEVERITY", "SERVICE", "TITLE"} {
+		d.table.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+
+	for i, incident := range incidents {
+		color := tcell.ColorWhite
+		if incident.Status == "triggered" {
+			color = tcell.ColorRed
+		}
+
+		severity := severities[incident.ID]
+
+		d.table.SetCell(i+1, 0, tview.NewTableCell(incident.ID).SetTextColor(color))
+		d.table.SetCell(i+1, 1, tview.NewTableCell(incident.Status).SetTextColor(color))
+		d.table.SetCell(i+1, 2, tview.NewTableCell(incident.Urgency))
+		d.table.SetCell(i+1, 3, tview.NewTableCell(severity).SetTextColor(severityColor(severity)))
+		d.table.SetCell(i+1, 4, tview.NewTableCell(incident.Service.Summary))
+		d.table.SetCell(i+1, 5, tview.NewTableCell(incident.Title).SetExpansion(1))
+	}
+}
+
+// filterIncidents shows the incidents at least as severe as the severity filter, most severe first,
+// and groups them by cluster. d.mu must be held.
+func (d *Dashboard) filterIncidents() {
+	d.incidents = nil
+	for _, incident := range d.all {
+		if d.minSeverity == "" || pd.SeverityRank(d.severities[incident.ID]) <= pd.SeverityRank(d.minSeverity) {
+			d.incidents = append(d.incidents, incident)
+		}
+	}
+
+	sort.SliceStable(d.incidents, func(i, j int) bool {
+		return pd.SeverityRank(d.severities[d.incidents[i].ID]) < pd.SeverityRank(d.severities[d.incidents[j].ID])
+	})
+
+	var enriched []pd.EnrichedIncident
+	for _, incident := range d.incidents {
+		enriched = append(enriched, pd.EnrichedIncident{Incident: incident, Alerts: d.alerts[incident.ID]})
+	}
+	d.groups = pd.GroupByCluster(enriched)
+}
+
+// cycleSeverityFilter steps the severity filter through all, critical, error and warning
+func (d *Dashboard) cycleSeverityFilter() {
+	d.mu.Lock()
+	switch d.minSeverity {
+	case "":
+		d.minSeverity = pd.SeverityCritical
+	case pd.SeverityCritical:
+		d.minSeverity = pd.SeverityError
+	case pd.SeverityError:
+		d.minSeverity = pd.SeverityWarning
+	default:
+		d.minSeverity = ""
+	}
+	minSeverity := d.minSeverity
+	d.filterIncidents()
+	d.mu.Unlock()
+
+	d.renderTable()
+
+	if minSeverity == "" {
+		d.setStatus(fmt.Sprintf("%v  [gray]showing all severities", helpText))
+	} else {
+		d.setStatus(fmt.Sprintf("%v  [gray]showing %v and above", helpText, minSeverity))
+	}
+}
+
+// severityColor returns the color a severity is shown in
+func severityColor(severity string) tcell.Color {
+	switch output.SeverityColor(severity) {
+	case "red":
+		return tcell.ColorRed
+	case "magenta":
+		return tcell.ColorFuchsia
+	case "yellow":
+		return tcell.ColorYellow
+	case "cyan":
+		return tcell.ColorAqua
+	default:
+		return tcell.ColorWhite
+	}
+}
+
+// selected returns the incident on the highlighted table row, if any
+func (d *Dashboard) selected() *pagerduty.Incident {
+	row, _ := d.table.GetSelection()
+	return d.incidentAt(row)
+}
+
+func (d *Dashboard) incidentAt(row int) *pagerduty.Incident {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.byCluster || row < 1 || row > len(d.incidents) {
+		return nil
+	}
+
+	incident := d.incidents[row-1]
+	return &incident
+}
+
+// expireSilences hands back the incidents whose timed silence has expired
+func (d *Dashboard) expireSilences() {
+	if d.silences == "" {
+		return
+	}
+
+	d.expiring.Lock()
+	defer d.expiring.Unlock()
+
+	store, err := silence.Load(d.silences)
+	if err != nil {
+		utils.ErrorLogger.Printf("Error while loading silences. The error message was : %s", err)
+		return
+	}
+
+	lifted, err := store.Expire(d.ctx, d.config.Client, d.config.CurrentUser, time.Now())
+	for _, l := range lifted {
+		utils.InfoLogger.Printf("Silence of %v expired", l.IncidentID)
+	}
+	if err != nil {
+		utils.ErrorLogger.Printf("Error while lifting expired silences. The error message was : %s", err)
+	}
+}
+
+func (d *Dashboard) setStatus(text string) {
+	d.status.SetText(text)
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PagerDuty/go-pagerduty"
+	"github.com/aliceh/alertops/pkg/output"
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	utils "github.com/aliceh/alertops/pkg/utils"
+	"github.com/rivo/tview"
+)
+
+// showDetails renders the incident on the given row, fetching its alerts the first time it is shown
+func (d *Dashboard) showDetails(row int) {
+	if g := d.groupAt(row); g != nil {
+		d.renderClusterDetails(g)
+		return
+	}
+
+	incident := d.incidentAt(row)
+	if incident == nil {
+		d.details.Clear()
+		return
+	}
+
+	d.mu.Lock()
+	alerts, ok := d.alerts[incident.ID]
+	d.mu.Unlock()
+
+	if ok {
+		d.renderDetails(incident, alerts)
+		return
+	}
+
+	d.renderDetails(incident, nil)
+	fmt.Fprintf(d.details, "\n[gray]Loading alerts...")
+
+	go func() {
+		alerts, err := fetchAlerts(d.ctx, d.config.Client, incident.ID)
+		if err != nil {
+			utils.ErrorLogger.Printf("Error while fetching alerts for incident %v. The error message was : %s", incident.ID, err)
+		}
+
+		d.mu.Lock()
+		d.alerts[incident.ID] = alerts
+		d.mu.Unlock()
+
+		d.app.QueueUpdateDraw(func() {
+			// The selection may have moved on while the alerts were loading
+			if current := d.selected(); current != nil && current.ID == incident.ID {
+				d.renderDetails(incident, alerts)
+				if err != nil {
+					fmt.Fprintf(d.details, "\n[red]%v", tview.Escape(err.Error()))
+				}
+			}
+		})
+	}()
+}
+
+func (d *Dashboard) renderDetails(incident *pagerduty.Incident, alerts []pd.Alert) {
+	d.details.Clear()
+	d.details.ScrollToBeginning()
+
+	fmt.Fprintf(d.details, "[yellow]%v[white]\n", tview.Escape(incident.Title))
+	field(d.details, "Incident", incident.ID)
+	field(d.details, "Status", incident.Status)
+	field(d.details, "Urgency", incident.Urgency)
+	field(d.details, "Service", incident.Service.Summary)
+	field(d.details, "Created", incident.CreatedAt)
+	for _, a := range incident.Assignments {
+		field(d.details, "Assigned", a.Assignee.Summary)
+	}
+	field(d.details, "URL", incident.HTMLURL)
+
+	for _, a := range alerts {
+		fmt.Fprintf(d.details, "\n[yellow]Alert %v[white]\n", a.AlertID)
+		field(d.details, "Name", a.Name)
+		field(d.details, "Status", a.Status)
+		if a.Severity != "" {
+			fmt.Fprintf(d.details, "[gray]Severity:[%v] %v[white]\n", output.SeverityColor(a.Severity), a.Severity)
+		}
+		field(d.details, "Cluster ID", a.ClusterID)
+		field(d.details, "Cluster", a.ClusterName)
+		field(d.details, "Console", a.Console)
+		field(d.details, "Hostname", a.Hostname)
+		field(d.details, "IP", a.IP)
+		field(d.details, "Last check-in", a.LastCheckIn)
+		field(d.details, "SOP", a.Sop)
+		field(d.details, "Parser", a.Parser)
+		if a.Partial {
+			fmt.Fprintf(d.details, "[red]Partially parsed:[white] %v\n", tview.Escape(a.ParseError))
+		}
+		labelTable(d.details, "Labels", a.AlertLabels)
+		labelTable(d.details, "Annotations", a.Annotations)
+	}
+}
+
+// field writes a label/value line, skipping values the alert parser left empty
+func field(w *tview.TextView, label, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(w, "[gray]%v:[white] %v\n", label, tview.Escape(value))
+}
+
+// labelTable writes a heading and one aligned key/value line per label
+func labelTable(w *tview.TextView, title string, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+
+	keys := pd.SortedKeys(labels)
+	width := 0
+	for _, k := range keys {
+		width = max(width, len(k))
+	}
+
+	fmt.Fprintf(w, "[gray]%v:[white]\n", title)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  [gray]%-*v[white]  %v\n", width, tview.Escape(k), tview.Escape(labels[k]))
+	}
+}
+
+func fetchAlerts(ctx context.Context, client pd.PagerDutyClient, id string) ([]pd.Alert, error) {
+	alerts, err := pd.GetAlertsWithContext(ctx, client, id, pagerduty.ListIncidentAlertsOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed []pd.Alert
+	for i := range alerts {
+		var a pd.Alert
+		// Partially parsed alerts are kept and flagged in the details view
+		if err := a.ParseAlertDataWithContext(ctx, client, &alerts[i]); err != nil {
+			utils.ErrorLogger.Printf("Error while parsing alert %v. The error message was : %s", alerts[i].ID, err)
+		}
+		parsed = append(parsed, a)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,227 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	utils "github.com/aliceh/alertops/pkg/utils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const runbookHelpText = "[yellow]Tab[white] next link  [yellow]Enter[white] follow  [yellow]b[white] back  [yellow]f[white] forward  [yellow]r[white] reload  [yellow]Esc[white] close"
+
+// runbook is a pane rendering the GitHub markdown runbook (SOP) linked from an alert. Links
+// in the document are numbered regions which can be cycled through and followed, and every
+// page visited is kept in a back/forward history.
+type runbook struct {
+	d      *Dashboard
+	layout *tview.Flex
+	view   *tview.TextView
+	status *tview.TextView
+
+	// links holds the targets of the links on the current page, indexed by region ID
+	links    []string
+	selected int
+
+	history []string
+	current int
+}
+
+func newRunbook(d *Dashboard) *runbook {
+	r := &runbook{
+		d:        d,
+		view:     tview.NewTextView(),
+		status:   tview.NewTextView(),
+		selected: -1,
+		current:  -1,
+	}
+
+	r.view.SetDynamicColors(true).SetRegions(true).SetWrap(true)
+	r.view.SetBorder(true)
+	r.view.SetInputCapture(r.handleKey)
+
+	r.status.SetDynamicColors(true).SetText(runbookHelpText)
+
+	r.layout = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(r.view, 0, 1, true).
+		AddItem(r.status, 1, 0, false)
+
+	return r
+}
+
+// open navigates to URL, dropping any forward history
+func (r *runbook) open(URL string) {
+	r.history = append(r.history[:r.current+1], URL)
+	r.current = len(r.history) - 1
+	r.load()
+}
+
+func (r *runbook) url() string {
+	if r.current < 0 {
+		return ""
+	}
+	return r.history[r.current]
+}
+
+// load fetches the current page in the background, showing an error state if it can't be rendered
+func (r *runbook) load() {
+	URL := r.url()
+
+	r.links = nil
+	r.selected = -1
+	r.status.SetText(runbookHelpText)
+	r.view.SetTitle(fmt.Sprintf(" Runbook: %v ", URL))
+	r.view.Highlight()
+	r.view.SetText("[gray]Loading...")
+
+	go func() {
+		doc, err := utils.FetchHTMLDoc(URL)
+
+		r.d.app.QueueUpdateDraw(func() {
+			// The user may have navigated elsewhere while the page was loading
+			if r.url() != URL {
+				return
+			}
+
+			if err != nil {
+				utils.ErrorLogger.Printf("Error while fetching runbook %v. The error message was : %s", URL, err)
+				r.view.SetText(fmt.Sprintf("[red]Failed to load runbook[white]\n\n%v\n\n%v\n\n[gray]Press r to retry, b to go back or Esc to close.",
+					tview.Escape(URL), tview.Escape(err.Error())))
+				return
+			}
+
+			r.links = utils.RenderHTMLDoc(doc, r.view)
+			r.view.ScrollToBeginning()
+		})
+	}()
+}
+
+func (r *runbook) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEscape:
+		r.d.closeRunbook()
+		return nil
+	case tcell.KeyTab:
+		r.selectLink(r.selected + 1)
+		return nil
+	case tcell.KeyBacktab:
+		r.selectLink(r.selected - 1)
+		return nil
+	case tcell.KeyEnter:
+		r.follow()
+		return nil
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		r.back()
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'q':
+		r.d.closeRunbook()
+		return nil
+	case 'b':
+		r.back()
+		return nil
+	case 'f':
+		r.forward()
+		return nil
+	case 'r':
+		r.load()
+		return nil
+	}
+
+	return event
+}
+
+// selectLink highlights the link with the given index, wrapping around at either end
+func (r *runbook) selectLink(i int) {
+	if len(r.links) == 0 {
+		return
+	}
+
+	r.selected = (i + len(r.links)) % len(r.links)
+	r.view.Highlight(strconv.Itoa(r.selected)).ScrollToHighlight()
+}
+
+func (r *runbook) follow() {
+	if r.selected < 0 || r.selected >= len(r.links) {
+		return
+	}
+
+	target, err := utils.ResolveLink(r.url(), r.links[r.selected])
+	if err != nil {
+		r.status.SetText(fmt.Sprintf("[red]Invalid link: %v", tview.Escape(err.Error())))
+		return
+	}
+
+	r.open(target)
+}
+
+func (r *runbook) back() {
+	if r.current > 0 {
+		r.current--
+		r.load()
+	}
+}
+
+func (r *runbook) forward() {
+	if r.current < len(r.history)-1 {
+		r.current++
+		r.load()
+	}
+}
+
+// openRunbook shows the SOP of the first alert of the selected incident that links to one, or the
+// first SOP of the selected cluster
+func (d *Dashboard) openRunbook() {
+	if g := d.selectedGroup(); g != nil {
+		if len(g.Sops) == 0 {
+			d.setStatus(fmt.Sprintf("[yellow]No runbook linked from the alerts of %v", tview.Escape(g.ClusterName)))
+			return
+		}
+		d.showRunbook(g.Sops[0])
+		return
+	}
+
+	incident := d.selected()
+	if incident == nil {
+		return
+	}
+
+	d.mu.Lock()
+	alerts, ok := d.alerts[incident.ID]
+	d.mu.Unlock()
+
+	if !ok {
+		d.setStatus("[yellow]Alerts are still loading")
+		return
+	}
+
+	var sop string
+	for _, a := range alerts {
+		if a.Sop != "" {
+			sop = a.Sop
+			break
+		}
+	}
+
+	if sop == "" {
+		d.setStatus(fmt.Sprintf("[yellow]No runbook linked from the alerts of %v", incident.ID))
+		return
+	}
+
+	d.showRunbook(sop)
+}
+
+func (d *Dashboard) showRunbook(sop string) {
+	r := newRunbook(d)
+	d.pages.AddPage(pageRunbook, r.layout, true, true)
+	d.app.SetFocus(r.view)
+	r.open(sop)
+}
+
+func (d *Dashboard) closeRunbook() {
+	d.pages.RemovePage(pageRunbook)
+	d.app.SetFocus(d.table)
+}
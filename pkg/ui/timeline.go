@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+
+	pd "github.com/aliceh/alertops/pkg/pagerduty"
+	utils "github.com/aliceh/alertops/pkg/utils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const timelineHelpText = "[yellow]m[white] save as Markdown  [yellow]Esc[white] close"
+
+// timelineColors are the colors of the entry types worth telling apart at a glance
+var timelineColors = map[string]string{
+	"trigger":     "red",
+	"escalate":    "red",
+	"acknowledge": "yellow",
+	"resolve":     "green",
+	"alert":       "orange",
+	"note":        "aqua",
+}
+
+// showTimeline opens a pane with the timeline of the selected incident, fetched in the background
+func (d *Dashboard) showTimeline() {
+	incident := d.selected()
+	if incident == nil {
+		return
+	}
+
+	view := tview.NewTextView().SetDynamicColors(true).SetWrap(true)
+	view.SetBorder(true).SetTitle(fmt.Sprintf(" Timeline: %v ", incident.ID))
+	view.SetText("[gray]Loading...")
+
+	status := tview.NewTextView().SetDynamicColors(true).SetText(timelineHelpText)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, true).
+		AddItem(status, 1, 0, false)
+
+	// Only set once loaded, from the UI goroutine
+	var timeline *pd.Timeline
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' {
+			d.pages.RemovePage(pageTimeline)
+			d.app.SetFocus(d.table)
+			return nil
+		}
+		if event.Rune() == 'm' && timeline != nil {
+			path := timeline.IncidentID + "-timeline.md"
+			if err := os.WriteFile(path, []byte(timeline.Markdown()), 0o644); err != nil {
+				status.SetText(fmt.Sprintf("[red]Failed to save %v: %v", tview.Escape(path), tview.Escape(err.Error())))
+			} else {
+				status.SetText(fmt.Sprintf("Saved %v  %v", tview.Escape(path), timelineHelpText))
+			}
+			return nil
+		}
+		return event
+	})
+
+	d.pages.AddPage(pageTimeline, layout, true, true)
+	d.app.SetFocus(view)
+
+	go func() {
+		t, err := pd.GetTimelineWithContext(d.ctx, d.config.Client, incident.ID)
+		if err != nil {
+			utils.ErrorLogger.Printf("Error while fetching the timeline of incident %v. The error message was : %s", incident.ID, err)
+		}
+
+		d.app.QueueUpdateDraw(func() {
+			if err != nil {
+				view.SetText(fmt.Sprintf("[red]Failed to load the timeline[white]\n\n%v", tview.Escape(err.Error())))
+				return
+			}
+			timeline = t
+			renderTimeline(view, t)
+		})
+	}()
+}
+
+func renderTimeline(w *tview.TextView, t *pd.Timeline) {
+	w.Clear()
+
+	fmt.Fprintf(w, "[yellow]%v[white]\n", tview.Escape(t.Title))
+	field(w, "Status", t.Status)
+	field(w, "Urgency", t.Urgency)
+	field(w, "Service", t.Service)
+	field(w, "Created", t.CreatedAt)
+	fmt.Fprintln(w)
+
+	for _, e := range t.Entries {
+		color := timelineColors[e.Type]
+		if color == "" {
+			color = "white"
+		}
+
+		fmt.Fprintf(w, "[gray]%v %8v %9v[white]  [%v]%-12v[white] %v", e.At, e.Elapsed, e.Delta, color, e.Type, tview.Escape(e.Actor))
+		if e.Transition != "" {
+			fmt.Fprintf(w, "  [%v]%v[white]", color, tview.Escape(e.Transition))
+		}
+		fmt.Fprintf(w, "\n    %v\n", tview.Escape(e.Summary))
+	}
+
+	w.ScrollToBeginning()
+}
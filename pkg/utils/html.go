@@ -7,7 +7,8 @@ import (
 	"golang.org/x/net/html"
 )
 
-var numLinks int = 0
+// links holds the targets of the links written by TraverseHTMLDoc, indexed by region ID
+var links []string
 
 func TraverseHTMLDoc(n *html.Node, textView *tview.TextView) int {
 	if n.Type == html.ElementNode {
@@ -15,15 +16,15 @@ func TraverseHTMLDoc(n *html.Node, textView *tview.TextView) int {
 		case "a":
 			for _, attr := range n.Attr {
 				if attr.Key == "href" {
-					fmt.Fprintf(textView, `["%d"][blue]%s - [white][""]`, numLinks, attr.Val)
-					numLinks++
+					fmt.Fprintf(textView, `["%d"][blue]%s - [white][""]`, len(links), tview.Escape(attr.Val))
+					links = append(links, attr.Val)
 				}
 			}
 		case "img":
 			for _, attr := range n.Attr {
 				if attr.Key == "src" {
-					fmt.Fprintf(textView, `["%d"]%s[""]`, numLinks, attr.Val)
-					numLinks++
+					fmt.Fprintf(textView, `["%d"]%s[""]`, len(links), tview.Escape(attr.Val))
+					links = append(links, attr.Val)
 				}
 			}
 		case "h1", "h2", "h3", "h4", "h5", "h6":
@@ -32,40 +33,52 @@ func TraverseHTMLDoc(n *html.Node, textView *tview.TextView) int {
 			fmt.Fprintf(textView, `[white]`)
 		}
 	} else if n.Type == html.TextNode {
-		fmt.Fprintf(textView, "%s", n.Data)
+		fmt.Fprintf(textView, "%s", tview.Escape(n.Data))
 	}
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
 		TraverseHTMLDoc(c, textView)
 	}
-	return numLinks
+	return len(links)
 }
 
-func FetchHTMLContent(URL string, textView *tview.TextView) {
-	textView.Clear()
-	numLinks = 0
-	owner, repo, path := getGitHubMdURL(URL)
+// FetchHTMLDoc downloads the GitHub markdown file behind URL and converts it into an HTML document.
+func FetchHTMLDoc(URL string) (*html.Node, error) {
+	owner, repo, path, err := getGitHubMdURL(URL)
+	if err != nil {
+		return nil, err
+	}
+
 	contents, err := GetGHReadme(owner, repo, path)
-	if (err) != nil {
-		ErrorLogger.Printf("Error while fetching readme contents. The error message was : %s", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch `%v`: %v", URL, err)
 	}
-	body := ConvertMarkdownToHTML(contents)
 
 	// Parse the HTML file
-	doc, err := html.Parse(body)
-	if (err) != nil {
-		ErrorLogger.Printf("Error while fetching readme contents. The error message was : %s", err)
+	doc, err := html.Parse(ConvertMarkdownToHTML(contents))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse `%v`: %v", URL, err)
 	}
+
+	return doc, nil
+}
+
+// RenderHTMLDoc writes doc into textView and returns the targets of its links, indexed by region ID.
+func RenderHTMLDoc(doc *html.Node, textView *tview.TextView) []string {
+	textView.Clear()
+	links = nil
 	TraverseHTMLDoc(doc, textView)
+	return links
+}
+
+// FetchHTMLContent renders the GitHub markdown file behind URL into textView and returns
+// the targets of its links, indexed by region ID.
+func FetchHTMLContent(URL string, textView *tview.TextView) ([]string, error) {
+	doc, err := FetchHTMLDoc(URL)
+	if err != nil {
+		ErrorLogger.Printf("Error while fetching readme contents. The error message was : %s", err)
+		textView.Clear()
+		return nil, err
+	}
 	// TODO : Work on Parsing Non Markdown Files
-	// doc, err := html.Parse(body)
-	// if err != nil {
-	// 	panic(err)
-	// }
-	// traverseHTMLDoc(doc, textView)
-	// text, err := html2text.FromHTMLNode(doc, html2text.Options{})
-	// fmt.Println(text)
-	// fmt.Fprint(textView, text)
-	// if err != nil {
-	// 	panic(err)
-	// }
+	return RenderHTMLDoc(doc, textView), nil
 }
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"net/url"
 	"strings"
 
 	"github.com/gomarkdown/markdown"
@@ -10,16 +12,36 @@ import (
 	"github.com/gomarkdown/markdown/parser"
 )
 
-func getGitHubMdURL(URL string) (owner, repo, path string) {
-	end := URL[len(URL)-3:]
-	host := URL[8:18]
-	if host == "github.com" && end == ".md" {
-		URL = strings.Replace(URL, "tree", "blob", -1)
-		owner, repo := GetOwnerAndRepoName(URL)
-		path := GetReadmePath(URL)
-		return owner, repo, path
+func getGitHubMdURL(URL string) (owner, repo, path string, err error) {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid URL `%v`: %v", URL, err)
 	}
-	return URL, "", ""
+
+	// Only markdown files hosted on GitHub can be rendered, i.e. https://github.com/<owner>/<repo>/blob/<ref>/<path>.md
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host != "github.com" || !strings.HasSuffix(u.Path, ".md") || len(segments) < 5 || (segments[2] != "blob" && segments[2] != "tree") {
+		return "", "", "", fmt.Errorf("`%v` is not a GitHub markdown file", URL)
+	}
+
+	u.Fragment = ""
+	URL = strings.Replace(u.String(), "/tree/", "/blob/", 1)
+	owner, repo = GetOwnerAndRepoName(URL)
+	path = GetReadmePath(URL)
+	return owner, repo, path, nil
+}
+
+// ResolveLink resolves a link found in the document at base, which may be relative, into an absolute URL.
+func ResolveLink(base, link string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	l, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(l).String(), nil
 }
 
 func GetOwnerAndRepoName(str string) (owner, repo string) {